@@ -7,27 +7,61 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/Lentz92/huggyfit/internal/calculator"
-	"github.com/Lentz92/huggyfit/internal/models"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "solve":
+			runSolve(os.Args[2:])
+			return
+		case "train":
+			runTrain(os.Args[2:])
+			return
+		}
+	}
+
 	// Setup command line flags
 	modelID := flag.String("model", "", "HuggingFace model ID (e.g., Qwen/Qwen2.5-0.5B)")
-	dtypeStr := flag.String("dtype", string(calculator.Float16),
-		"Data type for model loading (float16/f16, int8/q8, int4/q4)")
+	dtypeStr := flag.String("dtype", "",
+		"Data type for model loading ("+calculator.DescribeSupportedTypes()+
+			") (default: detected from the checkpoint, else float16)")
+	kvDtypeStr := flag.String("kv-dtype", string(calculator.Float16),
+		"Data type for the KV cache ("+calculator.DescribeKVCacheTypes()+")")
 	users := flag.Int("users", 1, "Number of concurrent users")
 	contextLen := flag.Int("context", 4096, "Context length per user")
+	encoderLen := flag.Int("encoder-length", 0,
+		"Encoder input tokens per user of encoder-decoder models (default: -context, Whisper: its 1500 audio frames)")
+	decoderLen := flag.Int("decoder-length", 0, "Decoder output tokens per user of encoder-decoder models (default: -context)")
 	estimateKV := flag.Bool("estimate-kv", false, "Use estimation for KV cache calculation")
+	strict := flag.Bool("strict", false,
+		"Exit with an error instead of falling back to the KV cache estimate or exceeding the model's context")
+	prefillOpts := registerPrefillFlags(flag.CommandLine)
+	tensorParallel := flag.Int("tp", 1, "Tensor parallel size (GPUs each layer is split across)")
+	pipelineParallel := flag.Int("pp", 1, "Pipeline parallel size (stages the layers are divided into)")
+	gpuSpecStr := flag.String("gpu", "", "GPUs to check the fit against (e.g. L4, A10G, 2xA100-80G, H100)")
+	gpuCatalogPath := flag.String("gpu-catalog", "",
+		"JSON file with additional GPUs (default: "+calculator.DefaultGPUCatalogPath()+" if present)")
+	overheadOpts := registerOverheadFlags(flag.CommandLine)
+	loraOpts := registerLoRAFlags(flag.CommandLine)
+	speculativeOpts := registerSpeculativeFlags(flag.CommandLine)
+	imageOpts := registerImageFlags(flag.CommandLine)
+	engineName := flag.String("engine", "",
+		"Serving engine to model memory allocation for ("+calculator.DescribeEngines()+")")
+	vllmOpts := registerVLLMFlags(flag.CommandLine)
 	verbose := flag.Bool("verbose", false, "Show detailed model information")
 	help := flag.Bool("help", false, "Show help message")
 
 	// Custom usage message
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "HuggyFit - GPU Memory Calculator for HuggingFace Models\n\n")
-		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s solve [options]  (run '%s solve -help' for details)\n", os.Args[0], os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s train [options]  (run '%s train -help' for details)\n\n", os.Args[0], os.Args[0])
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
@@ -35,6 +69,26 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  %s -model Qwen/Qwen2.5-0.5B -users 4\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "\n  # With specific context length\n")
 		fmt.Fprintf(os.Stderr, "  %s -model Qwen/Qwen2.5-0.5B -users 2 -context 8192\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n  # Exact weight size from the checkpoint's stored data types\n")
+		fmt.Fprintf(os.Stderr, "  %s -model Qwen/Qwen2.5-0.5B -dtype native\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n  # Per-GPU memory with tensor and pipeline parallelism on 8 GPUs\n")
+		fmt.Fprintf(os.Stderr, "  %s -model Qwen/Qwen2.5-72B -tp 4 -pp 2\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n  # Check whether 16 users fit on two A100 80GB GPUs\n")
+		fmt.Fprintf(os.Stderr, "  %s -model Qwen/Qwen2.5-32B -users 16 -gpu 2xA100-80G\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n  # KV cache blocks vLLM allocates on an L40S\n")
+		fmt.Fprintf(os.Stderr, "  %s -model Qwen/Qwen2.5-7B -engine vllm -gpu L40S\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n  # vLLM serving 32 resident LoRA adapters of rank up to 64\n")
+		fmt.Fprintf(os.Stderr, "  %s -model Qwen/Qwen2.5-7B -engine vllm -lora-rank 64 -max-loras 32\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n  # Speculative decoding with a draft model on the same GPU\n")
+		fmt.Fprintf(os.Stderr, "  %s -model Qwen/Qwen2.5-32B -draft-model Qwen/Qwen2.5-0.5B -users 4\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n  # Vision-language model with two 1024x1024 images per user\n")
+		fmt.Fprintf(os.Stderr, "  %s -model Qwen/Qwen2.5-VL-7B-Instruct -users 4 -images 2 -image-size 1024x1024\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n  # Encoder-decoder model with 512 input and 128 output tokens\n")
+		fmt.Fprintf(os.Stderr, "  %s -model google-t5/t5-large -users 32 -encoder-length 512 -decoder-length 128\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n  # Fail instead of estimating when the config is missing or invalid\n")
+		fmt.Fprintf(os.Stderr, "  %s -model Qwen/Qwen2.5-7B -users 8 -strict\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n  # INT4 weights with an FP8 KV cache\n")
+		fmt.Fprintf(os.Stderr, "  %s -model Qwen/Qwen2.5-0.5B -dtype int4 -kv-dtype fp8\n", os.Args[0])
 	}
 	flag.Parse()
 
@@ -49,67 +103,185 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Validate and normalize data type
-	dtype := calculator.NormalizeDataType(calculator.DataType(strings.ToLower(*dtypeStr)))
-	if !calculator.ValidateDataType(dtype) {
-		log.Printf("Error: unsupported data type: %s\n", dtype)
-		log.Printf("Supported types: float16/f16, int8/q8, int4/q4\n")
-		os.Exit(1)
+	dtype, kvDtype := parseDataTypes(*dtypeStr, *kvDtypeStr)
+	if *encoderLen < 0 || *decoderLen < 0 {
+		log.Fatalf("Error: -encoder-length and -decoder-length must not be negative")
 	}
 
-	// Fetch model information
-	modelInfo, err := models.FetchModelInfo(*modelID)
-	if err != nil {
-		log.Fatalf("Error fetching model information: %v", err)
+	parallel := calculator.ParallelConfig{
+		TensorParallel:   *tensorParallel,
+		PipelineParallel: *pipelineParallel,
+	}
+	if err := parallel.Validate(); err != nil {
+		log.Fatalf("Error: %v", err)
 	}
+	gpuSpec := resolveGPUSpec(*gpuSpecStr, *gpuCatalogPath, &parallel)
+	overhead := overheadOpts.resolve(flag.CommandLine)
+	engine := parseEngine(*engineName, vllmOpts)
+
+	model := loadModel(*modelID, dtype,
+		!*estimateKV || loraOpts.enabled() || speculativeOpts.enabled() || imageOpts.enabled())
+	modelInfo, config, configErr := model.info, model.config, model.configErr
+	dtype, dtypeSource, weights := model.dtype, model.dtypeSource, model.weights
 
-	// Calculate base memory requirements
-	baseMemory, err := calculator.CalculateGPUMemory(modelInfo.ParametersB, dtype)
-	if err != nil {
-		log.Fatalf("Error calculating base GPU memory: %v", err)
+	// Resident LoRA adapters are served next to the base weights
+	var adapters float64
+	var loraParams calculator.LoRAServingParams
+	if loraOpts.enabled() {
+		loraParams, adapters = loraOpts.memory(model)
+		weights += adapters
 	}
 
 	var kvMemory float64
+	var err error
+	kvParams := calculator.KVCacheParams{
+		Users:         *users,
+		ContextLength: *contextLen,
+		DataType:      dtype,
+		KVDataType:    kvDtype,
+		Strict:        *strict,
+
+		EncoderInputLength:  *encoderLen,
+		DecoderOutputLength: *decoderLen,
+	}
+
+	// Images join the context as vision tokens
+	var imageParams calculator.ImageParams
+	if imageOpts.enabled() {
+		imageParams, kvParams.ImageTokens = imageOpts.tokens(model)
+	}
+	if configErr == nil && config != nil {
+		checkContextLength(kvParams, config, *strict)
+	}
+
 	if !*estimateKV {
-		// Try to fetch model config for precise KV cache calculation
-		config, err := calculator.FetchModelConfig(*modelID)
-		if err == nil {
-			kvParams := calculator.KVCacheParams{
-				Users:         *users,
-				ContextLength: *contextLen,
-				DataType:      dtype,
-				Config:        config,
-			}
+		// Use the model config for precise KV cache calculation
+		if configErr == nil {
+			kvParams.Config = config
 			kvMemory, err = calculator.CalculateKVCache(kvParams)
 			if err != nil {
-				log.Printf("Warning: Failed to calculate precise KV cache: %v\n", err)
-				log.Printf("Falling back to estimation...\n")
+				fallBackToEstimate(*strict, "Failed to calculate precise KV cache", err)
+				kvParams.Config = nil
 				*estimateKV = true
 			}
 		} else {
-			log.Printf("Warning: Failed to fetch model config: %v\n", err)
-			log.Printf("Falling back to estimation...\n")
+			fallBackToEstimate(*strict, "Model config unavailable", configErr)
 			*estimateKV = true
 		}
 	}
 
 	if *estimateKV {
-		kvMemory = calculator.EstimateKVCache(modelInfo.ParametersB, *users, *contextLen, dtype)
+		kvMemory = calculator.EstimateKVCache(modelInfo.ParametersB, *users, *contextLen+kvParams.ImageTokens, kvDtype)
+	}
+
+	imageKV := 0.0
+	if imageOpts.enabled() && !*estimateKV {
+		imageKV, err = calculator.CalculateImageKVCache(kvParams)
+		if err != nil {
+			log.Fatalf("Error calculating image KV cache: %v", err)
+		}
+	}
+
+	// Speculative decoding adds a draft model or the model's own MTP layers
+	var draft calculator.DraftMemory
+	var draftSource string
+	if speculativeOpts.enabled() {
+		draft, draftSource = speculativeOpts.memory(model, kvParams, parallel)
+	}
+
+	// Peak prefill activations need the model config
+	activationParams := prefillOpts.params(*contextLen, config)
+	activations, perGPUActivations := 0.0, 0.0
+	if configErr == nil && config != nil {
+		activations, err = calculator.CalculateActivationMemory(activationParams, 1)
+		if err == nil {
+			perGPUActivations, err = calculator.CalculateActivationMemory(activationParams, parallel.TensorParallel)
+		}
+		if err != nil {
+			log.Fatalf("Error calculating activation memory: %v", err)
+		}
 	}
 
-	totalMemory := baseMemory + kvMemory
+	// A single GPU holding everything, with the overhead itemized separately
+	single := calculator.NewMemoryBreakdown("GPU", 1, weights+draft.WeightsGB, activations,
+		kvMemory+draft.KVCacheGB, overhead)
+	totalMemory := single.Total()
+
+	// Split memory across tensor- and pipeline-parallel ranks
+	var perGPU []calculator.MemoryBreakdown
+	if parallel.GPUs() > 1 {
+		perGPU, err = calculator.CalculateParallelMemory(calculator.ParallelParams{
+			ParametersB:   modelInfo.ParametersB,
+			WeightsGB:     weights,
+			ActivationsGB: perGPUActivations,
+			KVCacheGB:     kvMemory,
+			KV:            kvParams,
+			Parallel:      parallel,
+			Overhead:      overhead,
+			Draft:         draft,
+		})
+		if err != nil {
+			log.Fatalf("Error splitting memory across GPUs: %v", err)
+		}
+	}
 
 	// Display results
 	if *verbose {
 		fmt.Printf("\nModel Information:\n")
 		fmt.Printf("- Model ID: %s\n", modelInfo.ModelID)
 		fmt.Printf("- Author: %s\n", modelInfo.Author)
-		fmt.Printf("- Parameters: %.2fB\n", modelInfo.ParametersB)
+		if modelInfo.ParametersDerived {
+			fmt.Printf("- Parameters: %.2fB (derived from config.json)\n", modelInfo.ParametersB)
+		} else {
+			fmt.Printf("- Parameters: %.2fB\n", modelInfo.ParametersB)
+		}
+		if len(modelInfo.ParameterBreakdown) > 0 {
+			fmt.Printf("- Stored Data Types: %s\n", formatParameterBreakdown(modelInfo.ParameterBreakdown))
+		}
 		fmt.Printf("- Downloads: %d\n", modelInfo.Downloads)
 		fmt.Printf("- Likes: %d\n", modelInfo.Likes)
+		if config != nil {
+			fmt.Printf("- Attention: %s\n", config.AttentionSummary())
+			fmt.Printf("- Max Context: %s\n", config.ContextLimits())
+			if heads, ok := config.SpeculativeHeads(); ok {
+				fmt.Printf("- Speculative Heads: %s\n", heads)
+			}
+			if vision, ok := config.VisionEncoder(); ok {
+				fmt.Printf("- Vision Encoder: %d layers x %d hidden, %.2fB parameters\n",
+					vision.Layers, vision.HiddenSize, vision.ParamsB)
+			}
+			if moe, ok := config.AnalyzeMoE(modelInfo.ParametersB); ok {
+				printMoEDetails(moe, model.weights)
+			}
+		}
 		fmt.Printf("\nMemory Requirements:\n")
-		fmt.Printf("- Data Type: %s\n", dtype)
-		fmt.Printf("- Base Model Memory: %.2f GB\n", baseMemory)
+		fmt.Printf("- Data Type: %s (%s)\n", dtype, dtypeSource)
+		if config != nil && config.QuantizationConfig != nil {
+			fmt.Printf("- Quantization: %s\n", config.QuantizationConfig)
+		}
+		fmt.Printf("- KV Cache Data Type: %s\n", kvDtype)
+		fmt.Printf("- Weights Memory: %.2f GB\n", single.WeightsGB)
+		if config != nil {
+			if vision, ok := config.VisionEncoder(); ok {
+				fmt.Printf("- Vision Encoder Weights: %.2f GB (included in weights)\n",
+					model.weights*vision.ParamsB/modelInfo.ParametersB)
+			}
+		}
+		if loraOpts.enabled() {
+			printLoRAAdapters(loraParams, adapters)
+		}
+		if speculativeOpts.enabled() {
+			printSpeculative(draft, draftSource)
+		}
+		if imageOpts.enabled() {
+			printImages(imageParams, kvParams.ImageTokens, imageKV, *estimateKV)
+		}
+		fmt.Printf("- Overhead: %.2f GB (%s)\n", single.OverheadGB, overhead)
+		if config != nil {
+			fmt.Printf("- Activations: %.2f GB (peak prefill)\n", single.ActivationsGB)
+		} else {
+			fmt.Printf("- Activations: not included (model config unavailable)\n")
+		}
 		fmt.Printf("- KV Cache Memory: %.2f GB (%s)\n",
 			kvMemory,
 			map[bool]string{true: "estimated", false: "precise"}[*estimateKV])
@@ -117,9 +289,187 @@ func main() {
 		fmt.Printf("- Total GPU Memory: %.2f GB\n", totalMemory)
 		fmt.Printf("- Users: %d\n", *users)
 		fmt.Printf("- Context Length: %d tokens\n", *contextLen)
+		if config != nil && config.IsEncoderDecoder {
+			lengths := kvParams
+			lengths.Config = config
+			encoder, decoder := lengths.EncoderDecoderLengths()
+			fmt.Printf("- Encoder Input: %d tokens, Decoder Output: %d tokens\n", encoder, decoder)
+		}
 	} else {
 		fmt.Printf("Estimated GPU memory requirement for %s:\n", modelInfo.ModelID)
-		fmt.Printf("- Total: %.2f GB (%s)\n", totalMemory, dtype)
+		fmt.Printf("- Total: %.2f GB (%s, KV cache %s)\n", totalMemory, dtype, kvDtype)
 		fmt.Printf("- Per User: %.2f GB\n", kvMemory/float64(*users))
+		if loraOpts.enabled() {
+			printLoRAAdapters(loraParams, adapters)
+		}
+		if speculativeOpts.enabled() {
+			printSpeculative(draft, draftSource)
+		}
+		if imageOpts.enabled() {
+			printImages(imageParams, kvParams.ImageTokens, imageKV, *estimateKV)
+		}
+	}
+
+	if len(perGPU) > 0 {
+		printPerGPUBreakdown(perGPU, parallel)
+	}
+
+	// Engines allocate memory their own way, the fit is checked against what they allocate
+	servesWorkload := true
+	if engine != nil {
+		// The draft model's weights load with the target's, its KV cache shares the engine's pool
+		engineParams := calculator.EngineParams{
+			ParametersB: modelInfo.ParametersB,
+			WeightsGB:   weights,
+			KV:          kvParams,
+			Parallel:    parallel,
+			Overhead:    overhead,
+			Draft:       draft,
+		}
+		if gpuSpec != nil {
+			engineParams.GPUMemoryGB = gpuSpec.GPU.MemoryGB
+		}
+		estimate, err := engine.Estimate(engineParams)
+		if err != nil {
+			log.Fatalf("Error estimating %s memory: %v", engine.Name(), err)
+		}
+		printEngineEstimate(engine, estimate)
+		perGPU = estimate.PerGPU
+		servesWorkload = estimate.ServesWorkload()
+	}
+
+	if gpuSpec != nil {
+		if len(perGPU) == 0 {
+			perGPU = []calculator.MemoryBreakdown{single}
+		}
+		printFitVerdict(calculator.CheckFit(*gpuSpec, perGPU), dtype, kvDtype)
+		if !servesWorkload {
+			fmt.Printf("Warning: %s cannot serve %d users at %d tokens at once\n",
+				engine.Name(), *users, *contextLen)
+		}
+	}
+}
+
+// printFitVerdict shows whether the deployment fits and the headroom per GPU
+func printFitVerdict(result calculator.FitResult, dtype, kvDtype calculator.DataType) {
+	fmt.Printf("\nGPU Fit (%s, %.0f GB each):\n", result.Spec, result.Spec.GPU.MemoryGB)
+	for _, fit := range result.PerGPU {
+		status := "fits"
+		if !fit.Fits() {
+			status = "does not fit"
+		}
+		fmt.Printf("- %s: %.2f GB required, %.2f GB headroom (%s)\n",
+			fit.Label, fit.RequiredGB, fit.HeadroomGB, status)
+	}
+
+	for i, t := range []calculator.DataType{dtype, kvDtype} {
+		if i > 0 && t == dtype {
+			continue
+		}
+		if !result.Spec.GPU.Supports(t) {
+			fmt.Printf("- Note: %s has no native %s support\n", result.Spec.GPU.Name, t)
+		}
+	}
+
+	if result.Fits() {
+		fmt.Printf("Verdict: fits on %s\n", result.Spec)
+	} else {
+		fmt.Printf("Verdict: does not fit on %s\n", result.Spec)
+	}
+}
+
+// printEngineEstimate shows the memory the engine allocates per GPU and its own figures
+func printEngineEstimate(engine calculator.EngineProfile, estimate calculator.EngineEstimate) {
+	fmt.Printf("\nEngine: %s (%s)\n", engine.Name(), engine.Description())
+	for _, detail := range estimate.Details {
+		fmt.Printf("- %s: %s\n", detail.Name, detail.Value)
+	}
+	for _, b := range estimate.PerGPU {
+		printBreakdown(b)
+	}
+	status := "enough"
+	if !estimate.ServesWorkload() {
+		status = "not enough"
+	}
+	fmt.Printf("- KV cache capacity: %d tokens for %d required (%s)\n",
+		estimate.KVTokens, estimate.RequiredTokens, status)
+	if estimate.MaxSequences > 0 && estimate.Users > estimate.MaxSequences {
+		fmt.Printf("- Users: %d exceed the %d sequences the engine runs at once\n",
+			estimate.Users, estimate.MaxSequences)
+	}
+}
+
+// printPerGPUBreakdown shows the memory each GPU needs under tensor and pipeline parallelism
+func printPerGPUBreakdown(perGPU []calculator.MemoryBreakdown, parallel calculator.ParallelConfig) {
+	fmt.Printf("\nPer-GPU Memory (TP=%d, PP=%d, %d GPUs):\n",
+		parallel.TensorParallel, parallel.PipelineParallel, parallel.GPUs())
+	for _, b := range perGPU {
+		printBreakdown(b)
+	}
+	if len(perGPU) > 1 {
+		fmt.Printf("- Peak: %.2f GB per GPU\n", calculator.PeakBreakdown(perGPU).Total())
+	}
+}
+
+// printLoRAAdapters shows the memory of the resident LoRA adapters
+func printLoRAAdapters(params calculator.LoRAServingParams, memory float64) {
+	fmt.Printf("- LoRA Adapters: %.2f GB (%d x rank %d, included in weights)\n",
+		memory, params.MaxLoRAs, params.LoRA.Rank)
+}
+
+// printSpeculative shows the memory speculative decoding adds
+func printSpeculative(draft calculator.DraftMemory, source string) {
+	fmt.Printf("- Speculative Decoding: %s, weights %.2f GB, KV cache %.2f GB (included in the totals)\n",
+		source, draft.WeightsGB, draft.KVCacheGB)
+}
+
+// printImages shows the vision tokens of each user's images and their KV cache
+func printImages(params calculator.ImageParams, tokens int, kvMemory float64, estimated bool) {
+	fmt.Printf("- Images: %d x %dx%d per user, %d vision tokens", params.Images, params.Width, params.Height, tokens)
+	if !estimated {
+		fmt.Printf(", KV cache %.2f GB", kvMemory)
+	}
+	fmt.Printf(" (included in the KV cache)\n")
+}
+
+// printBreakdown shows the memory components of a GPU
+func printBreakdown(b calculator.MemoryBreakdown) {
+	fmt.Printf("- %s: Weights %.2f GB, Overhead %.2f GB, Activations %.2f GB, KV Cache %.2f GB, Total %.2f GB\n",
+		b.Label, b.WeightsGB, b.OverheadGB, b.ActivationsGB, b.KVCacheGB, b.Total())
+}
+
+// formatParameterBreakdown formats a safetensors dtype breakdown, largest first
+func formatParameterBreakdown(breakdown map[string]int64) string {
+	dtypes := make([]string, 0, len(breakdown))
+	for dtype := range breakdown {
+		dtypes = append(dtypes, dtype)
+	}
+	sort.Slice(dtypes, func(i, j int) bool {
+		return breakdown[dtypes[i]] > breakdown[dtypes[j]]
+	})
+
+	parts := make([]string, len(dtypes))
+	for i, dtype := range dtypes {
+		parts[i] = fmt.Sprintf("%s %.2fB", dtype, float64(breakdown[dtype])/1e9)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printMoEDetails shows total vs active parameters and the per-GPU weight memory
+// under expert parallelism, scaled from the weight memory
+func printMoEDetails(moe *calculator.MoEInfo, weights float64) {
+	fmt.Printf("\nMixture of Experts:\n")
+	fmt.Printf("- Experts: %d routed (%d active per token), %d shared, %d MoE layers\n",
+		moe.NumExperts, moe.ExpertsPerToken, moe.SharedExperts, moe.MoELayers)
+	fmt.Printf("- Total Parameters: %.2fB\n", moe.TotalParamsB)
+	fmt.Printf("- Active Parameters: %.2fB\n", moe.ActiveParamsB)
+	fmt.Printf("- Per-Expert Weights: %.2fB parameters, %.2f GB\n",
+		moe.ExpertParamsB, weights*moe.ExpertParamsB/moe.TotalParamsB)
+	for _, ep := range []int{2, 4, 8} {
+		if ep > moe.NumExperts {
+			break
+		}
+		fmt.Printf("- Expert Parallel x%d: %.2f GB weights per GPU\n",
+			ep, weights*moe.ExpertParallelFraction(ep))
 	}
 }
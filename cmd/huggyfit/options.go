@@ -0,0 +1,448 @@
+// cmd/huggyfit/options.go
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Lentz92/huggyfit/internal/calculator"
+	"github.com/Lentz92/huggyfit/internal/models"
+)
+
+// loadedModel holds everything fetched and derived for a model
+type loadedModel struct {
+	info        *models.ModelInfo
+	config      *calculator.ModelConfig
+	configErr   error
+	dtype       calculator.DataType
+	dtypeSource string
+	weights     float64 // Weight memory on a single GPU
+}
+
+// overheadFlags holds the flags that override the overhead model
+type overheadFlags struct {
+	config        *string
+	fixed         *float64
+	proportional  *float64
+	engineReserve *float64
+}
+
+// registerOverheadFlags adds the overhead flags to a flag set
+func registerOverheadFlags(fs *flag.FlagSet) overheadFlags {
+	defaults := calculator.DefaultOverhead()
+	return overheadFlags{
+		config: fs.String("overhead-config", "",
+			"JSON file with the overhead model (default: "+calculator.DefaultOverheadConfigPath()+" if present)"),
+		fixed: fs.Float64("overhead-fixed", defaults.FixedGB,
+			"Fixed overhead per GPU in GB (CUDA context, cuBLAS workspace)"),
+		proportional: fs.Float64("overhead-proportional", defaults.Proportional,
+			"Overhead as a fraction of the weight memory"),
+		engineReserve: fs.Float64("engine-reserve", defaults.EngineReserveGB,
+			"Memory reserved by the serving engine per GPU in GB"),
+	}
+}
+
+// resolve loads the overhead config and applies the flags set on the command line
+func (f overheadFlags) resolve(fs *flag.FlagSet) calculator.OverheadModel {
+	if *f.config != "" {
+		if err := calculator.LoadOverheadConfig(*f.config); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	} else if err := calculator.LoadDefaultOverheadConfig(); err != nil {
+		log.Printf("Warning: %v\n", err)
+	}
+
+	overhead := calculator.DefaultOverhead()
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "overhead-fixed":
+			overhead.FixedGB = *f.fixed
+		case "overhead-proportional":
+			overhead.Proportional = *f.proportional
+		case "engine-reserve":
+			overhead.EngineReserveGB = *f.engineReserve
+		}
+	})
+	if err := overhead.Validate(); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	return overhead
+}
+
+// parseDataTypes validates and normalizes the weight and KV cache data types.
+// An empty weight data type means detect it from the checkpoint.
+func parseDataTypes(dtypeStr, kvDtypeStr string) (calculator.DataType, calculator.DataType) {
+	dtype := calculator.NormalizeDataType(calculator.DataType(strings.ToLower(dtypeStr)))
+	if dtype != "" && dtype != calculator.Native && !calculator.ValidateDataType(dtype) {
+		log.Printf("Error: unsupported data type: %s\n", dtype)
+		log.Printf("Supported types: %s\n", calculator.DescribeSupportedTypes())
+		os.Exit(1)
+	}
+
+	kvDtype := calculator.NormalizeDataType(calculator.DataType(strings.ToLower(kvDtypeStr)))
+	if !calculator.ValidateKVDataType(kvDtype) {
+		log.Printf("Error: unsupported KV cache data type: %s\n", kvDtype)
+		log.Printf("Supported KV cache types: %s\n", calculator.DescribeKVCacheTypes())
+		os.Exit(1)
+	}
+
+	return dtype, kvDtype
+}
+
+// resolveGPUSpec loads user-defined GPUs and parses the GPU spec. Several GPUs
+// without explicit parallelism default to tensor parallelism.
+func resolveGPUSpec(specStr, catalogPath string, parallel *calculator.ParallelConfig) *calculator.GPUSpec {
+	if catalogPath != "" {
+		if err := calculator.LoadGPUCatalog(catalogPath); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	} else if err := calculator.LoadDefaultGPUCatalog(); err != nil {
+		log.Printf("Warning: %v\n", err)
+	}
+
+	if specStr == "" {
+		return nil
+	}
+
+	spec, err := calculator.ParseGPUSpec(specStr)
+	if err != nil {
+		log.Printf("Error: %v\n", err)
+		log.Printf("Known GPUs: %s\n", describeGPUs())
+		os.Exit(1)
+	}
+
+	if parallel.GPUs() == 1 && spec.Count > 1 {
+		parallel.TensorParallel = spec.Count
+	}
+	if parallel.GPUs() != spec.Count {
+		log.Fatalf("Error: TP x PP (%d) must equal the number of GPUs in -gpu (%d)",
+			parallel.GPUs(), spec.Count)
+	}
+	return &spec
+}
+
+// loadModel fetches the model information and config, resolves the weight data
+// type and calculates the weight memory
+func loadModel(modelID string, dtype calculator.DataType, fetchConfig bool) *loadedModel {
+	info, err := models.FetchModelInfo(modelID)
+	if err != nil {
+		log.Fatalf("Error fetching model information: %v", err)
+	}
+	model := &loadedModel{info: info, dtype: dtype, dtypeSource: "specified"}
+	if info.ParametersDerived {
+		log.Printf("Warning: %s has no usable safetensors parameter count, using %.2fB parameters derived from config.json\n",
+			modelID, info.ParametersB)
+	}
+
+	// Fetch model config for data type detection and precise KV cache calculation
+	if fetchConfig || dtype == "" {
+		model.config, model.configErr = calculator.FetchModelConfig(modelID)
+	}
+	if model.configErr == nil && model.config != nil {
+		// An invalid config is treated as unavailable, so nothing divides by its zero dimensions
+		if err := model.config.Validate(); err != nil {
+			model.config, model.configErr = nil, fmt.Errorf("invalid config.json: %w", err)
+		}
+	}
+
+	// Default to the data type the checkpoint is stored in
+	if model.dtype == "" {
+		model.dtype = calculator.Float16
+		model.dtypeSource = "default"
+		if model.configErr == nil {
+			if detected, ok := model.config.CheckpointDataType(); ok {
+				model.dtype = detected
+				model.dtypeSource = "detected from checkpoint"
+			}
+		}
+	}
+
+	// Calculate weight memory requirements
+	if model.dtype == calculator.Native {
+		model.weights, err = calculator.CalculateNativeWeightMemory(info.ParameterBreakdown)
+	} else {
+		model.weights, err = calculator.CalculateQuantizedWeightMemory(model.config, info.ParametersB, model.dtype)
+	}
+	if err != nil {
+		log.Fatalf("Error calculating weight memory: %v", err)
+	}
+
+	return model
+}
+
+// fallBackToEstimate warns that the KV cache is estimated from the parameter
+// count, or exits when -strict refuses estimates
+func fallBackToEstimate(strict bool, reason string, err error) {
+	if strict {
+		log.Fatalf("Error: %s: %v (-strict refuses to estimate the KV cache)", reason, err)
+	}
+	log.Printf("Warning: %s: %v\n", reason, err)
+	log.Printf("Falling back to estimation...\n")
+}
+
+// checkContextLength warns when the requested context exceeds what the model
+// supports, or exits when -strict is set. Contexts beyond a sliding window that
+// every layer uses fit but only attend to the window.
+func checkContextLength(kv calculator.KVCacheParams, config *calculator.ModelConfig, strict bool) {
+	kv.Config = config
+	if err := kv.CheckContextLength(); err != nil {
+		if strict {
+			log.Fatalf("Error: %v (-strict refuses to exceed it)", err)
+		}
+		log.Printf("Warning: %v\n", err)
+	}
+	if window := config.ContextLimits().SlidingWindow; window > 0 && kv.ContextLength > window {
+		log.Printf("Note: every layer attends to the last %d tokens only (sliding_window)\n", window)
+	}
+}
+
+// vllmFlags holds the flags for vLLM's PagedAttention settings
+type vllmFlags struct {
+	blockSize            *int
+	gpuMemoryUtilization *float64
+	maxNumSeqs           *int
+	maxNumBatchedTokens  *int
+}
+
+// registerVLLMFlags adds the vLLM flags to a flag set
+func registerVLLMFlags(fs *flag.FlagSet) vllmFlags {
+	defaults := calculator.DefaultPagedKVCacheParams()
+	return vllmFlags{
+		blockSize:            fs.Int("block-size", defaults.BlockSize, "vLLM tokens per KV cache block"),
+		gpuMemoryUtilization: fs.Float64("gpu-memory-utilization", defaults.GPUMemoryUtilization, "vLLM share of the GPU memory to use"),
+		maxNumSeqs:           fs.Int("max-num-seqs", defaults.MaxNumSeqs, "vLLM maximum sequences per iteration"),
+		maxNumBatchedTokens:  fs.Int("max-num-batched-tokens", defaults.MaxNumBatchedTokens, "vLLM maximum tokens per iteration"),
+	}
+}
+
+// settings returns the PagedAttention settings from the flags
+func (f vllmFlags) settings() calculator.PagedKVCacheParams {
+	return calculator.PagedKVCacheParams{
+		BlockSize:            *f.blockSize,
+		GPUMemoryUtilization: *f.gpuMemoryUtilization,
+		MaxNumSeqs:           *f.maxNumSeqs,
+		MaxNumBatchedTokens:  *f.maxNumBatchedTokens,
+	}
+}
+
+// prefillFlags holds the flags shaping the prefill forward pass
+type prefillFlags struct {
+	chunk           *int
+	batch           *int
+	lastTokenLogits *bool
+}
+
+// registerPrefillFlags adds the prefill flags to a flag set
+func registerPrefillFlags(fs *flag.FlagSet) prefillFlags {
+	return prefillFlags{
+		chunk: fs.Int("prefill-chunk", 0, "Prompt tokens per sequence in one forward pass (default: the whole context)"),
+		batch: fs.Int("prefill-batch", 1, "Sequences prefilled together"),
+		lastTokenLogits: fs.Bool("last-token-logits", false,
+			"Only compute logits for the last prompt token, as serving engines do"),
+	}
+}
+
+// params returns the activation parameters for a context length
+func (f prefillFlags) params(contextLength int, config *calculator.ModelConfig) calculator.ActivationParams {
+	if *f.chunk < 0 || *f.batch < 1 {
+		log.Fatalf("Error: -prefill-chunk must not be negative and -prefill-batch must be at least 1")
+	}
+	return calculator.ActivationParams{
+		ContextLength:   contextLength,
+		PrefillChunk:    *f.chunk,
+		Batch:           *f.batch,
+		LastTokenLogits: *f.lastTokenLogits,
+		Config:          config,
+	}
+}
+
+// loraFlags holds the flags for serving LoRA adapters next to the base model
+type loraFlags struct {
+	rank     *int
+	maxLoRAs *int
+	targets  *string
+}
+
+// registerLoRAFlags adds the LoRA serving flags to a flag set
+func registerLoRAFlags(fs *flag.FlagSet) loraFlags {
+	return loraFlags{
+		rank:     fs.Int("lora-rank", 0, "Largest LoRA adapter rank served (vLLM --max-lora-rank), 0 for no adapters"),
+		maxLoRAs: fs.Int("max-loras", 1, "LoRA adapters resident on the GPU at once (vLLM --max-loras)"),
+		targets: fs.String("lora-targets", "all-linear",
+			"Comma-separated LoRA target modules ("+calculator.DescribeLoRATargets()+")"),
+	}
+}
+
+// enabled reports whether adapters are served
+func (f loraFlags) enabled() bool {
+	return *f.rank > 0
+}
+
+// memory returns the memory of the resident adapters for the model's weights
+func (f loraFlags) memory(model *loadedModel) (calculator.LoRAServingParams, float64) {
+	targets, err := calculator.ParseLoRATargets(*f.targets)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if model.configErr != nil {
+		log.Fatalf("Error: LoRA adapter sizes need the model config: %v", model.configErr)
+	}
+
+	params := calculator.LoRAServingParams{
+		LoRA:     calculator.LoRAParams{Rank: *f.rank, TargetModules: targets},
+		MaxLoRAs: *f.maxLoRAs,
+	}
+	memory, err := calculator.CalculateLoRAServingMemory(model.config, params, model.dtype)
+	if err != nil {
+		log.Fatalf("Error calculating LoRA adapter memory: %v", err)
+	}
+	return params, memory
+}
+
+// speculativeFlags holds the flags for speculative decoding
+type speculativeFlags struct {
+	draftModel *string
+	draftDtype *string
+	tokens     *int
+	mtp        *bool
+}
+
+// registerSpeculativeFlags adds the speculative decoding flags to a flag set
+func registerSpeculativeFlags(fs *flag.FlagSet) speculativeFlags {
+	return speculativeFlags{
+		draftModel: fs.String("draft-model", "", "HuggingFace ID of a draft model, EAGLE or Medusa head for speculative decoding"),
+		draftDtype: fs.String("draft-dtype", "", "Data type of the draft model (default: detected from its checkpoint)"),
+		tokens:     fs.Int("speculative-tokens", 5, "Tokens proposed per speculative decoding step"),
+		mtp:        fs.Bool("mtp", false, "Use the model's built-in multi-token prediction or Medusa heads for speculative decoding"),
+	}
+}
+
+// enabled reports whether speculative decoding is used
+func (f speculativeFlags) enabled() bool {
+	return *f.draftModel != "" || *f.mtp
+}
+
+// memory returns the memory speculative decoding adds to the target model's
+// workload and a description of what proposes the tokens
+func (f speculativeFlags) memory(target *loadedModel, kv calculator.KVCacheParams,
+	parallel calculator.ParallelConfig) (calculator.DraftMemory, string) {
+	if *f.draftModel != "" && *f.mtp {
+		log.Fatalf("Error: use either -draft-model or -mtp")
+	}
+
+	if *f.mtp {
+		if target.configErr != nil || target.config == nil {
+			log.Fatalf("Error: multi-token prediction needs the model config: %v", target.configErr)
+		}
+		heads, ok := target.config.SpeculativeHeads()
+		if !ok || (heads.Method != calculator.SpeculativeMTP && heads.Method != calculator.SpeculativeMedusa) {
+			log.Fatalf("Error: %s has no multi-token prediction or Medusa heads", target.info.ModelID)
+		}
+		kv.Config = target.config
+		kvMemory, err := calculator.CalculateMTPKVCache(kv, *f.tokens, 1)
+		if err != nil {
+			log.Fatalf("Error calculating multi-token prediction KV cache: %v", err)
+		}
+		perRank, err := calculator.CalculateMTPKVCache(kv, *f.tokens, parallel.TensorParallel)
+		if err != nil {
+			log.Fatalf("Error calculating multi-token prediction KV cache: %v", err)
+		}
+		return calculator.DraftMemory{Method: heads.Method, KVCacheGB: kvMemory, KVCachePerRankGB: perRank},
+			heads.String()
+	}
+
+	dtype, _ := parseDataTypes(*f.draftDtype, string(calculator.Float16))
+	draft := loadModel(*f.draftModel, dtype, true)
+	if draft.configErr != nil {
+		if kv.Strict {
+			log.Fatalf("Error: Failed to fetch draft model config: %v (-strict refuses to estimate the KV cache)",
+				draft.configErr)
+		}
+		log.Printf("Warning: Failed to fetch draft model config: %v\n", draft.configErr)
+		log.Printf("Estimating the draft KV cache...\n")
+		draft.config = nil
+	}
+	memory, err := calculator.CalculateDraftMemory(calculator.DraftParams{
+		ParametersB:       draft.info.ParametersB,
+		DataType:          draft.dtype,
+		Config:            draft.config,
+		SpeculativeTokens: *f.tokens,
+	}, kv)
+	if err != nil {
+		log.Fatalf("Error calculating draft model memory: %v", err)
+	}
+	return memory, fmt.Sprintf("%s (%s, %s)", draft.info.ModelID, memory.Method, draft.dtype)
+}
+
+// imageFlags holds the flags for the images users send to multimodal models
+type imageFlags struct {
+	images     *int
+	resolution *string
+}
+
+// registerImageFlags adds the image flags to a flag set
+func registerImageFlags(fs *flag.FlagSet) imageFlags {
+	return imageFlags{
+		images:     fs.Int("images", 0, "Images per user for multimodal models, cached as vision tokens"),
+		resolution: fs.String("image-size", "1024x1024", "Image resolution as WIDTHxHEIGHT in pixels"),
+	}
+}
+
+// enabled reports whether users send images
+func (f imageFlags) enabled() bool {
+	return *f.images > 0
+}
+
+// tokens returns the image parameters and the vision tokens per user
+func (f imageFlags) tokens(model *loadedModel) (calculator.ImageParams, int) {
+	var width, height int
+	if _, err := fmt.Sscanf(strings.ToLower(*f.resolution), "%dx%d", &width, &height); err != nil {
+		log.Fatalf("Error: invalid -image-size %q, expected WIDTHxHEIGHT", *f.resolution)
+	}
+	if model.configErr != nil {
+		log.Fatalf("Error: image tokens need the model config: %v", model.configErr)
+	}
+
+	params := calculator.ImageParams{Images: *f.images, Width: width, Height: height}
+	tokens, err := params.ImageTokens(model.config)
+	if err != nil {
+		log.Fatalf("Error: %s: %v", model.info.ModelID, err)
+	}
+	return params, tokens
+}
+
+// parseEngine looks up the serving engine profile, nil when no engine is given.
+// vLLM uses the PagedAttention settings from the flags.
+func parseEngine(name string, vllm vllmFlags) calculator.EngineProfile {
+	if name == "" {
+		return nil
+	}
+	engine, ok := calculator.LookupEngine(name)
+	if !ok {
+		log.Printf("Error: unsupported engine: %s\n", name)
+		log.Printf("Supported engines: %s\n", calculator.DescribeEngines())
+		os.Exit(1)
+	}
+
+	if engine.Name() == calculator.EngineVLLM {
+		settings := vllm.settings()
+		if err := settings.Validate(); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		engine = calculator.NewVLLMEngine(settings)
+	}
+	return engine
+}
+
+// describeGPUs lists the names of the GPUs in the catalog
+func describeGPUs() string {
+	names := make([]string, 0, len(calculator.GetGPUs()))
+	for _, gpu := range calculator.GetGPUs() {
+		names = append(names, gpu.Name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,154 @@
+// cmd/huggyfit/solve.go
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Lentz92/huggyfit/internal/calculator"
+)
+
+// runSolve finds the most users or the longest context that fit a GPU budget
+func runSolve(args []string) {
+	fs := flag.NewFlagSet("solve", flag.ExitOnError)
+	modelID := fs.String("model", "", "HuggingFace model ID (e.g., Qwen/Qwen2.5-0.5B)")
+	dtypeStr := fs.String("dtype", "",
+		"Data type for model loading ("+calculator.DescribeSupportedTypes()+
+			") (default: detected from the checkpoint, else float16)")
+	kvDtypeStr := fs.String("kv-dtype", string(calculator.Float16),
+		"Data type for the KV cache ("+calculator.DescribeKVCacheTypes()+")")
+	users := fs.Int("users", 0, "Fixed number of concurrent users, solves for the longest context")
+	contextLen := fs.Int("context", 4096, "Fixed context length per user, solves for the most users")
+	budget := fs.Float64("budget", 0, "Memory budget per GPU in GB (alternative to -gpu)")
+	tensorParallel := fs.Int("tp", 1, "Tensor parallel size (GPUs each layer is split across)")
+	pipelineParallel := fs.Int("pp", 1, "Pipeline parallel size (stages the layers are divided into)")
+	strict := fs.Bool("strict", false,
+		"Exit with an error instead of falling back to the KV cache estimate or exceeding the model's context")
+	gpuSpecStr := fs.String("gpu", "", "GPUs providing the memory budget (e.g. L4, 2xA100-80G)")
+	gpuCatalogPath := fs.String("gpu-catalog", "",
+		"JSON file with additional GPUs (default: "+calculator.DefaultGPUCatalogPath()+" if present)")
+	overheadOpts := registerOverheadFlags(fs)
+	prefillOpts := registerPrefillFlags(fs)
+	loraOpts := registerLoRAFlags(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "HuggyFit - GPU Memory Calculator for HuggingFace Models\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: %s solve [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Finds the most concurrent users at a context length, or the longest\n")
+		fmt.Fprintf(os.Stderr, "context for a number of users, that fit the GPU memory.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  # How many users fit on an L4 at 8k context\n")
+		fmt.Fprintf(os.Stderr, "  %s solve -model Qwen/Qwen2.5-7B -gpu L4 -context 8192\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n  # Longest context for 16 users on two A100 80GB GPUs\n")
+		fmt.Fprintf(os.Stderr, "  %s solve -model Qwen/Qwen2.5-32B -gpu 2xA100-80G -users 16\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n  # Custom budget of 40 GB\n")
+		fmt.Fprintf(os.Stderr, "  %s solve -model Qwen/Qwen2.5-7B -budget 40 -context 32768\n", os.Args[0])
+	}
+	fs.Parse(args)
+
+	if *modelID == "" {
+		fmt.Println("Error: model ID is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if (*gpuSpecStr == "") == (*budget <= 0) {
+		fmt.Println("Error: exactly one of -gpu or -budget is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	dtype, kvDtype := parseDataTypes(*dtypeStr, *kvDtypeStr)
+
+	parallel := calculator.ParallelConfig{
+		TensorParallel:   *tensorParallel,
+		PipelineParallel: *pipelineParallel,
+	}
+	if err := parallel.Validate(); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	gpuSpec := resolveGPUSpec(*gpuSpecStr, *gpuCatalogPath, &parallel)
+	overhead := overheadOpts.resolve(fs)
+	budgetGB, budgetSource := *budget, "custom budget"
+	if gpuSpec != nil {
+		budgetGB, budgetSource = gpuSpec.GPU.MemoryGB, gpuSpec.String()
+	}
+
+	model := loadModel(*modelID, dtype, true)
+	var adapters float64
+	var loraParams calculator.LoRAServingParams
+	if loraOpts.enabled() {
+		loraParams, adapters = loraOpts.memory(model)
+	}
+	params := calculator.SolveParams{
+		BudgetGB:    budgetGB,
+		ParametersB: model.info.ParametersB,
+		WeightsGB:   model.weights + adapters,
+		KV: calculator.KVCacheParams{
+			Users:         *users,
+			ContextLength: *contextLen,
+			DataType:      model.dtype,
+			KVDataType:    kvDtype,
+			Strict:        *strict,
+		},
+		Parallel: parallel,
+		Overhead: overhead,
+	}
+	kvMode := "estimated"
+	if model.configErr == nil {
+		params.KV.Config = model.config
+		kvMode = "precise"
+		activations := prefillOpts.params(*contextLen, model.config)
+		params.Activations = &activations
+		if *users == 0 {
+			checkContextLength(params.KV, model.config, *strict)
+		}
+	} else {
+		fallBackToEstimate(*strict, "Model config unavailable", model.configErr)
+	}
+
+	fmt.Printf("Capacity of %s on %s (%.2f GB per GPU):\n", model.info.ModelID, budgetSource, budgetGB)
+	fmt.Printf("- Data Type: %s (%s), KV cache %s (%s)\n", model.dtype, model.dtypeSource, kvDtype, kvMode)
+	if parallel.GPUs() > 1 {
+		fmt.Printf("- Parallelism: TP %d, PP %d\n", parallel.TensorParallel, parallel.PipelineParallel)
+	}
+	fmt.Printf("- Overhead: %s\n", overhead)
+	if loraOpts.enabled() {
+		printLoRAAdapters(loraParams, adapters)
+	}
+	if params.Activations == nil {
+		fmt.Printf("- Activations: not included (model config unavailable)\n")
+	}
+
+	// -users fixes the number of users and solves for context, otherwise solve for users
+	if *users > 0 {
+		maxContext, err := calculator.MaxContextLength(params)
+		if err != nil {
+			log.Fatalf("Error solving for context length: %v", err)
+		}
+		if maxContext == 0 {
+			fmt.Printf("Result: %d users do not fit at any context length\n", *users)
+			return
+		}
+		fmt.Printf("Result: up to %d tokens of context for %d users", maxContext, *users)
+		if params.KV.Config != nil && maxContext == params.KV.Config.ContextLimits().Max {
+			fmt.Printf(" (model maximum)")
+		}
+		fmt.Printf("\n")
+		return
+	}
+
+	maxUsers, err := calculator.MaxUsers(params)
+	if err != nil {
+		log.Fatalf("Error solving for users: %v", err)
+	}
+	if maxUsers == 0 {
+		fmt.Printf("Result: not even one user fits at %d tokens of context\n", *contextLen)
+		return
+	}
+	fmt.Printf("Result: up to %d concurrent users at %d tokens of context\n", maxUsers, *contextLen)
+}
@@ -0,0 +1,179 @@
+// cmd/huggyfit/train.go
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Lentz92/huggyfit/internal/calculator"
+)
+
+// runTrain estimates the memory of fine-tuning a model
+func runTrain(args []string) {
+	fs := flag.NewFlagSet("train", flag.ExitOnError)
+	modelID := fs.String("model", "", "HuggingFace model ID (e.g., Qwen/Qwen2.5-0.5B)")
+	methodStr := fs.String("method", string(calculator.TrainingFull),
+		"Training method ("+calculator.DescribeTrainingMethods()+")")
+	dtypeStr := fs.String("dtype", string(calculator.BFloat16),
+		"Data type of the weights, gradients and activations (the QLoRA base model is nf4)")
+	optimizerStr := fs.String("optimizer", string(calculator.OptimizerAdamW),
+		"Optimizer ("+calculator.DescribeOptimizers()+")")
+	microBatch := fs.Int("micro-batch", 1, "Sequences per GPU in one forward and backward pass")
+	seqLen := fs.Int("seq-len", 2048, "Tokens per training sequence")
+	checkpointing := fs.Bool("gradient-checkpointing", false, "Recompute layer activations in the backward pass")
+	masterWeights := fs.Bool("master-weights", true, "Keep fp32 master weights for full fine-tuning in half precision")
+	loraRank := fs.Int("lora-rank", 16, "LoRA adapter rank")
+	loraTargets := fs.String("lora-targets", "all-linear",
+		"Comma-separated LoRA target modules ("+calculator.DescribeLoRATargets()+")")
+	shardingStr := fs.String("sharding", string(calculator.ShardingNone),
+		"Sharding across data-parallel GPUs ("+calculator.DescribeShardingStrategies()+")")
+	dataParallel := fs.Int("dp", 0, "Data parallel size (default: the number of GPUs in -gpu, else 1)")
+	gpusPerNode := fs.Int("gpus-per-node", 8, "GPUs per node, the shard group of fsdp-hybrid and the GPUs sharing host memory")
+	offloadOptimizer := fs.Bool("offload-optimizer", false, "Offload optimizer states to CPU memory (ZeRO-Offload, FSDP CPU offload)")
+	offloadParams := fs.Bool("offload-params", false, "Offload parameter shards to CPU memory (zero3, fsdp-full, fsdp-hybrid)")
+	gpuSpecStr := fs.String("gpu", "", "GPUs to check the fit against, one per data-parallel rank (e.g. L4, 8xA100-80G)")
+	gpuCatalogPath := fs.String("gpu-catalog", "",
+		"JSON file with additional GPUs (default: "+calculator.DefaultGPUCatalogPath()+" if present)")
+	overheadOpts := registerOverheadFlags(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "HuggyFit - GPU Memory Calculator for HuggingFace Models\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: %s train [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Estimates the GPU memory of a training step: weights, gradients,\n")
+		fmt.Fprintf(os.Stderr, "optimizer states and stored activations.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  # Full fine-tuning with AdamW at 4k tokens\n")
+		fmt.Fprintf(os.Stderr, "  %s train -model Qwen/Qwen2.5-1.5B -seq-len 4096\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n  # LoRA rank 64 on the attention projections with gradient checkpointing\n")
+		fmt.Fprintf(os.Stderr, "  %s train -model Qwen/Qwen2.5-7B -method lora -lora-rank 64 -lora-targets attention -gradient-checkpointing\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n  # QLoRA with 8-bit AdamW on an L4\n")
+		fmt.Fprintf(os.Stderr, "  %s train -model Qwen/Qwen2.5-7B -method qlora -optimizer adamw-8bit -micro-batch 4 -gpu L4\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n  # ZeRO-3 with optimizer offload on eight A100 80GB GPUs\n")
+		fmt.Fprintf(os.Stderr, "  %s train -model Qwen/Qwen2.5-32B -sharding zero3 -offload-optimizer -gpu 8xA100-80G\n", os.Args[0])
+	}
+	fs.Parse(args)
+
+	if *modelID == "" {
+		fmt.Println("Error: model ID is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	method, ok := calculator.ParseTrainingMethod(*methodStr)
+	if !ok {
+		log.Fatalf("Error: unknown training method %q. Supported methods: %s",
+			*methodStr, calculator.DescribeTrainingMethods())
+	}
+	optimizer, ok := calculator.LookupOptimizer(*optimizerStr)
+	if !ok {
+		log.Fatalf("Error: unknown optimizer %q. Supported optimizers: %s",
+			*optimizerStr, calculator.DescribeOptimizers())
+	}
+	dtype, _ := parseDataTypes(*dtypeStr, string(calculator.Float16))
+
+	var lora calculator.LoRAParams
+	if method != calculator.TrainingFull {
+		targets, err := calculator.ParseLoRATargets(*loraTargets)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		lora = calculator.LoRAParams{Rank: *loraRank, TargetModules: targets}
+	}
+
+	strategy, ok := calculator.ParseShardingStrategy(*shardingStr)
+	if !ok {
+		log.Fatalf("Error: unknown sharding strategy %q. Supported strategies: %s",
+			*shardingStr, calculator.DescribeShardingStrategies())
+	}
+
+	// Every GPU in -gpu is a data-parallel rank
+	parallel := calculator.ParallelConfig{TensorParallel: 1, PipelineParallel: 1}
+	gpuSpec := resolveGPUSpec(*gpuSpecStr, *gpuCatalogPath, &parallel)
+	if *dataParallel == 0 {
+		*dataParallel = 1
+		if gpuSpec != nil {
+			*dataParallel = gpuSpec.Count
+		}
+	}
+	if gpuSpec != nil && gpuSpec.Count != *dataParallel {
+		log.Fatalf("Error: -dp (%d) must equal the number of GPUs in -gpu (%d)", *dataParallel, gpuSpec.Count)
+	}
+	sharding := calculator.ShardingParams{
+		Strategy:          strategy,
+		DataParallel:      *dataParallel,
+		GPUsPerNode:       *gpusPerNode,
+		OffloadOptimizer:  *offloadOptimizer,
+		OffloadParameters: *offloadParams,
+	}
+	if err := sharding.Validate(); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	overhead := overheadOpts.resolve(fs)
+
+	model := loadModel(*modelID, dtype, true)
+	if model.configErr != nil {
+		log.Fatalf("Error fetching model config: %v", model.configErr)
+	}
+
+	estimate, err := calculator.CalculateTrainingMemory(calculator.TrainingParams{
+		ParametersB:           model.info.ParametersB,
+		Method:                method,
+		DataType:              dtype,
+		MasterWeights:         *masterWeights,
+		Optimizer:             optimizer.Name,
+		MicroBatch:            *microBatch,
+		SequenceLength:        *seqLen,
+		GradientCheckpointing: *checkpointing,
+		LoRA:                  lora,
+		Sharding:              sharding,
+		Config:                model.config,
+		Overhead:              overhead,
+	})
+	if err != nil {
+		log.Fatalf("Error calculating training memory: %v", err)
+	}
+
+	b := estimate.Breakdown
+	fmt.Printf("Training %s (%s):\n", model.info.ModelID, method)
+	fmt.Printf("- Base weights: %s, trainable parameters: %s\n",
+		estimate.BaseDataType, formatParameterCount(estimate.TrainableParameters))
+	if method != calculator.TrainingFull {
+		fmt.Printf("- LoRA: rank %d on %v\n", lora.Rank, lora.TargetModules)
+	}
+	fmt.Printf("- Optimizer: %s (%s)\n", optimizer.Name, optimizer.Description)
+	fmt.Printf("- Micro-batch: %d x %d tokens", *microBatch, *seqLen)
+	if *checkpointing {
+		fmt.Printf(", gradient checkpointing")
+	}
+	fmt.Printf("\n")
+	if sharding.DataParallel > 1 || strategy != calculator.ShardingNone {
+		fmt.Printf("- Sharding: %s\n", sharding)
+		fmt.Printf("Per-GPU Memory:\n")
+	}
+	fmt.Printf("- Weights: %.2f GB\n", b.WeightsGB)
+	fmt.Printf("- Gradients: %.2f GB\n", b.GradientsGB)
+	fmt.Printf("- Optimizer States: %.2f GB\n", b.OptimizerGB)
+	fmt.Printf("- Activations: %.2f GB\n", b.ActivationsGB)
+	fmt.Printf("- Overhead: %.2f GB (%s)\n", b.OverheadGB, overhead)
+	fmt.Printf("Total Memory Required: %.2f GB per GPU\n", b.Total())
+	if estimate.HostRAMGB > 0 {
+		fmt.Printf("Host Memory Required: %.2f GB per node for offloaded states\n", estimate.HostRAMGB)
+	}
+
+	if gpuSpec != nil {
+		printFitVerdict(calculator.CheckFit(*gpuSpec, []calculator.MemoryBreakdown{b}), dtype, dtype)
+	}
+}
+
+// formatParameterCount formats a parameter count in millions or billions
+func formatParameterCount(count int64) string {
+	if count >= 1e9 {
+		return fmt.Sprintf("%.2fB", float64(count)/1e9)
+	}
+	return fmt.Sprintf("%.1fM", float64(count)/1e6)
+}
@@ -6,11 +6,21 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/Lentz92/huggyfit/internal/calculator"
 	"github.com/Lentz92/huggyfit/internal/tui"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
+	// User-defined GPUs extend the built-in catalog and overhead.json replaces
+	// the default overhead model
+	if err := calculator.LoadDefaultGPUCatalog(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	if err := calculator.LoadDefaultOverheadConfig(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
 	p := tea.NewProgram(
 		tui.InitialModel(),
 		tea.WithAltScreen(),       // Use alternate screen buffer
@@ -3,6 +3,7 @@
 package cache
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -14,6 +15,7 @@ type CacheKey struct {
 	Users      int
 	ContextLen int
 	DataType   calculator.DataType
+	KVDataType calculator.DataType
 }
 
 type CacheEntry struct {
@@ -22,9 +24,16 @@ type CacheEntry struct {
 	ExpiresAt time.Time
 }
 
+// KVCacheValue is a KV cache size in GB and whether it was estimated from the
+// parameter count because the model config is missing or invalid
+type KVCacheValue struct {
+	MemoryGB  float64
+	Estimated bool
+}
+
 type Cache struct {
 	configs      map[string]*calculator.ModelConfig
-	calculations map[CacheKey]float64
+	calculations map[CacheKey]KVCacheValue
 	mu           sync.RWMutex
 	expiration   time.Duration
 }
@@ -32,7 +41,7 @@ type Cache struct {
 func NewCache(expiration time.Duration) *Cache {
 	return &Cache{
 		configs:      make(map[string]*calculator.ModelConfig),
-		calculations: make(map[CacheKey]float64),
+		calculations: make(map[CacheKey]KVCacheValue),
 		expiration:   expiration,
 	}
 }
@@ -50,71 +59,73 @@ func (c *Cache) SetConfig(modelID string, config *calculator.ModelConfig) {
 	c.mu.Unlock()
 }
 
-func (c *Cache) GetKVCache(key CacheKey) (float64, bool) {
+func (c *Cache) GetKVCache(key CacheKey) (KVCacheValue, bool) {
 	c.mu.RLock()
 	value, exists := c.calculations[key]
 	c.mu.RUnlock()
 	return value, exists
 }
 
-func (c *Cache) SetKVCache(key CacheKey, value float64) {
+func (c *Cache) SetKVCache(key CacheKey, value KVCacheValue) {
 	c.mu.Lock()
 	c.calculations[key] = value
 	c.mu.Unlock()
 }
 
-// GetOrCalculateKVCache tries to get cached KV calculation or computes it if not found
+// GetOrFetchConfig returns the cached model config or fetches it from HuggingFace
+func (c *Cache) GetOrFetchConfig(modelID string) (*calculator.ModelConfig, error) {
+	if config, exists := c.GetConfig(modelID); exists {
+		return config, nil
+	}
+
+	config, err := calculator.FetchModelConfig(modelID)
+	if err != nil {
+		return nil, err
+	}
+	c.SetConfig(modelID, config)
+	return config, nil
+}
+
+// GetOrCalculateKVCache tries to get cached KV calculation or computes it if not
+// found. Without a usable model config the KV cache is estimated from the
+// parameter count and marked as such, or, when strict, an error is returned.
 func (c *Cache) GetOrCalculateKVCache(
 	key CacheKey,
 	parameters float64,
-	useEstimation bool,
-) float64 {
+	strict bool,
+) (KVCacheValue, error) {
 	// Try to get from cache first
 	if cachedValue, exists := c.GetKVCache(key); exists {
-		return cachedValue
+		return cachedValue, nil
 	}
 
-	var result float64
-	if !useEstimation {
-		// Try to get cached config
-		config, exists := c.GetConfig(key.ModelID)
-		if !exists {
-			config, err := calculator.FetchModelConfig(key.ModelID)
-			if err == nil {
-				c.SetConfig(key.ModelID, config)
-
-				kvParams := calculator.KVCacheParams{
-					Users:         key.Users,
-					ContextLength: key.ContextLen,
-					DataType:      key.DataType,
-					Config:        config,
-				}
-
-				result, err = calculator.CalculateKVCache(kvParams)
-				if err == nil {
-					c.SetKVCache(key, result)
-					return result
-				}
-			}
-		} else {
-			kvParams := calculator.KVCacheParams{
-				Users:         key.Users,
-				ContextLength: key.ContextLen,
-				DataType:      key.DataType,
-				Config:        config,
-			}
-
-			var err error
-			result, err = calculator.CalculateKVCache(kvParams)
-			if err == nil {
-				c.SetKVCache(key, result)
-				return result
-			}
+	config, err := c.GetOrFetchConfig(key.ModelID)
+	if err == nil {
+		kvParams := calculator.KVCacheParams{
+			Users:         key.Users,
+			ContextLength: key.ContextLen,
+			DataType:      key.DataType,
+			KVDataType:    key.KVDataType,
+			Config:        config,
+		}
+
+		var result float64
+		result, err = calculator.CalculateKVCache(kvParams)
+		if err == nil {
+			value := KVCacheValue{MemoryGB: result}
+			c.SetKVCache(key, value)
+			return value, nil
 		}
 	}
+	if strict {
+		return KVCacheValue{}, fmt.Errorf("%w: %w", calculator.ErrEstimateRefused{Reason: "no usable model config"}, err)
+	}
 
 	// Fallback to estimation
-	result = calculator.EstimateKVCache(parameters, key.Users, key.ContextLen, key.DataType)
-	c.SetKVCache(key, result)
-	return result
+	value := KVCacheValue{
+		MemoryGB:  calculator.EstimateKVCache(parameters, key.Users, key.ContextLen, key.KVDataType),
+		Estimated: true,
+	}
+	c.SetKVCache(key, value)
+	return value, nil
 }
@@ -0,0 +1,67 @@
+// internal/cache/cache_test.go
+
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Lentz92/huggyfit/internal/calculator"
+)
+
+func TestGetOrCalculateKVCache(t *testing.T) {
+	// meta-llama/Meta-Llama-3-8B and the same config without its layer count
+	llama3 := &calculator.ModelConfig{
+		ModelType:         "llama",
+		HiddenSize:        4096,
+		NumAttentionHeads: 32,
+		NumHiddenLayers:   32,
+		NumKeyValueHeads:  8,
+		VocabSize:         128256,
+	}
+	invalid := *llama3
+	invalid.NumHiddenLayers = 0
+
+	tests := []struct {
+		name          string
+		config        *calculator.ModelConfig
+		strict        bool
+		wantEstimated bool
+		wantErr       bool
+	}{
+		{"valid config", llama3, false, false, false},
+		{"invalid config is estimated", &invalid, false, true, false},
+		{"strict refuses the estimate", &invalid, true, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(time.Hour)
+			c.SetConfig("model", tt.config)
+			key := CacheKey{ModelID: "model", Users: 1, ContextLen: 8192,
+				DataType: calculator.BFloat16, KVDataType: calculator.BFloat16}
+
+			value, err := c.GetOrCalculateKVCache(key, 8.03, tt.strict)
+			if tt.wantErr {
+				var refused calculator.ErrEstimateRefused
+				if !errors.As(err, &refused) {
+					t.Fatalf("GetOrCalculateKVCache() error = %v, want ErrEstimateRefused", err)
+				}
+				if _, cached := c.GetKVCache(key); cached {
+					t.Error("refused estimate was cached")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetOrCalculateKVCache() error = %v", err)
+			}
+			if value.Estimated != tt.wantEstimated || value.MemoryGB <= 0 {
+				t.Errorf("GetOrCalculateKVCache() = %+v, want Estimated %v", value, tt.wantEstimated)
+			}
+			if cached, _ := c.GetKVCache(key); cached != value {
+				t.Errorf("GetKVCache() = %+v, want %+v", cached, value)
+			}
+		})
+	}
+}
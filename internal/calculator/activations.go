@@ -0,0 +1,86 @@
+// internal/calculator/activations.go
+
+package calculator
+
+import "fmt"
+
+// ActivationParams holds parameters for estimating peak activation memory
+type ActivationParams struct {
+	ContextLength   int      // Prompt tokens per sequence
+	PrefillChunk    int      // Tokens per sequence in one forward pass, 0 for the whole context
+	Batch           int      // Sequences prefilled together, defaults to 1
+	LastTokenLogits bool     // Only the last token of each sequence is projected to logits
+	DataType        DataType // Data type of the activations, defaults to float16
+	Config          *ModelConfig
+}
+
+// tokens returns the number of tokens in one forward pass
+func (p ActivationParams) tokens() int {
+	chunk := p.ContextLength
+	if p.PrefillChunk > 0 {
+		chunk = min(p.PrefillChunk, p.ContextLength)
+	}
+	return max(p.Batch, 1) * chunk
+}
+
+// CalculateActivationMemory estimates the peak activation memory of a prefill
+// forward pass in GB, split over tensor-parallel ranks.
+//
+// Layers run one at a time, so the peak is the larger of:
+//   - a decoder layer: the residual stream and its normalized copy, the q/k/v
+//     projections and the gate, up and activated MLP intermediates
+//   - the final projection: the last hidden states and the fp32 logits
+//     (tokens × vocab_size × 4 bytes), which dominate for long prompts
+//
+// Attention scores are not materialized, as with FlashAttention or SDPA.
+func CalculateActivationMemory(params ActivationParams, tensorParallel int) (float64, error) {
+	config := params.Config
+	if config == nil {
+		return 0, fmt.Errorf("model config is required for activation memory calculation")
+	}
+
+	dtype := params.DataType
+	if dtype == "" {
+		dtype = Float16
+	}
+	bytes, ok := BytesPerParameter(dtype)
+	if !ok {
+		return 0, ErrUnsupportedDataType{dtype}
+	}
+
+	tp := float64(max(tensorParallel, 1))
+	tokens := float64(params.tokens())
+	hidden := float64(config.HiddenSize)
+
+	// Sharded projections are divided over tensor-parallel ranks
+	qkv := float64(config.qkvWidth()) / tp
+	mlp := 3 * float64(config.activeIntermediateSize()) / tp
+	layerPeak := tokens * (2*hidden + max(qkv, mlp)) * bytes
+
+	logitTokens := tokens
+	if params.LastTokenLogits {
+		logitTokens = float64(max(params.Batch, 1))
+	}
+	logits := logitTokens * float64(config.VocabSize) / tp * bytesPerFloat32
+	outputPeak := tokens*hidden*bytes + logits
+
+	return round(max(layerPeak, outputPeak)/bytesPerGiB, 2), nil
+}
+
+// activeIntermediateSize returns the MLP width each token passes through: the
+// dense intermediate size, or the routed and shared experts of MoE models
+func (c *ModelConfig) activeIntermediateSize() int {
+	if !c.IsMoE() || c.MoEIntermediateSize == 0 {
+		return c.IntermediateSize
+	}
+	size := c.NumExpertsPerTok * c.MoEIntermediateSize
+	if c.SharedExpertIntermediateSize > 0 {
+		return size + c.SharedExpertIntermediateSize
+	}
+	return size + c.NSharedExperts*c.MoEIntermediateSize
+}
+
+// qkvWidth returns the combined output width of the query, key and value projections
+func (c *ModelConfig) qkvWidth() int {
+	return (c.NumAttentionHeads + 2*c.NumKeyValueHeads) * c.headDim()
+}
@@ -0,0 +1,104 @@
+// internal/calculator/activations_test.go
+
+package calculator
+
+import "testing"
+
+func TestCalculateActivationMemory(t *testing.T) {
+	tests := []struct {
+		name   string
+		params ActivationParams
+		tp     int
+		want   float64 // GiB
+	}{
+		{
+			// The fp32 logits of 8192 tokens over a 128256 vocabulary are 3.91 GiB
+			name:   "full prompt logits dominate",
+			params: ActivationParams{ContextLength: 8192},
+			tp:     1,
+			want:   3.98,
+		},
+		{
+			// Residual stream plus the 3 x 14336 MLP intermediates of 8192 tokens
+			name:   "last token logits leave the decoder layer peak",
+			params: ActivationParams{ContextLength: 8192, LastTokenLogits: true},
+			tp:     1,
+			want:   0.78,
+		},
+		{
+			name:   "tensor parallel shards the logits",
+			params: ActivationParams{ContextLength: 8192},
+			tp:     2,
+			want:   2.02,
+		},
+		{
+			name:   "tensor parallel shards the layer",
+			params: ActivationParams{ContextLength: 8192, LastTokenLogits: true},
+			tp:     2,
+			want:   0.45,
+		},
+		{
+			name:   "chunked prefill of a batch",
+			params: ActivationParams{ContextLength: 8192, PrefillChunk: 2048, Batch: 2},
+			tp:     1,
+			want:   1.99,
+		},
+		{
+			name:   "fp32 activations",
+			params: ActivationParams{ContextLength: 8192, LastTokenLogits: true, DataType: Float32},
+			tp:     1,
+			want:   1.56,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			params.Config = llama3Config()
+			got, err := CalculateActivationMemory(params, tt.tp)
+			if err != nil {
+				t.Fatalf("CalculateActivationMemory() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CalculateActivationMemory() = %.2f GB, want %.2f GB", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := CalculateActivationMemory(ActivationParams{ContextLength: 8192}, 1); err == nil {
+		t.Error("expected an error without a model config")
+	}
+	params := ActivationParams{ContextLength: 8192, DataType: "fp12", Config: llama3Config()}
+	if _, err := CalculateActivationMemory(params, 1); err == nil {
+		t.Error("expected an error for an unsupported activation data type")
+	}
+}
+
+func TestActiveIntermediateSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *ModelConfig
+		want   int
+	}{
+		{"dense", llama3Config(), 14336},
+		{"mixtral experts share intermediate_size", mixtralConfig(), 14336},
+		{
+			name: "routed and shared experts",
+			config: &ModelConfig{NRoutedExperts: 256, NumExpertsPerTok: 8, MoEIntermediateSize: 2048,
+				NSharedExperts: 1, IntermediateSize: 18432},
+			want: 9 * 2048,
+		},
+		{
+			name: "shared expert with its own width",
+			config: &ModelConfig{NumExperts: 60, NumExpertsPerTok: 4, MoEIntermediateSize: 1408,
+				SharedExpertIntermediateSize: 5632, IntermediateSize: 5632},
+			want: 4*1408 + 5632,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.config.activeIntermediateSize(); got != tt.want {
+			t.Errorf("%s: activeIntermediateSize() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// internal/calculator/attention.go
+
+package calculator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// attentionKind identifies how a decoder layer caches keys and values
+type attentionKind int
+
+const (
+	attentionFull    attentionKind = iota // Caches every token of the context
+	attentionSliding                      // Caches at most sliding_window tokens
+	attentionLatent                       // Caches a compressed latent per token (MLA)
+	attentionCross                        // Caches the image tokens it cross-attends to, no text tokens
+	attentionSSM                          // Keeps a fixed-size recurrent state instead (Mamba, RWKV)
+	attentionHybrid                       // Caches every token and keeps a recurrent state
+)
+
+// slidingWindowPatterns lists families that interleave local and global layers
+// when config.json omits sliding_window_pattern: every Nth layer is global
+var slidingWindowPatterns = map[string]int{
+	"gemma2":      2,
+	"gemma3":      6,
+	"gemma3_text": 6,
+	"cohere2":     4,
+}
+
+// slidingWindowOptIn lists families that only use their sliding_window when
+// use_sliding_window is set
+var slidingWindowOptIn = map[string]bool{
+	"qwen2":     true,
+	"qwen2_moe": true,
+	"qwen3":     true,
+	"qwen3_moe": true,
+}
+
+// architectureFamilies maps architecture class names to model families for
+// configs that omit model_type
+var architectureFamilies = map[string]string{
+	"Gemma2ForCausalLM":  "gemma2",
+	"Gemma3ForCausalLM":  "gemma3_text",
+	"Cohere2ForCausalLM": "cohere2",
+	"Qwen2ForCausalLM":   "qwen2",
+}
+
+// family returns the model family used to dispatch architecture specifics
+func (c *ModelConfig) family() string {
+	if c.ModelType != "" {
+		return strings.ToLower(c.ModelType)
+	}
+	for _, arch := range c.Architectures {
+		if family, ok := architectureFamilies[arch]; ok {
+			return family
+		}
+	}
+	return ""
+}
+
+// headDim returns the attention head dimension, preferring an explicit head_dim.
+// Models without attention heads, as Mamba, have none.
+func (c *ModelConfig) headDim() int {
+	switch {
+	case c.HeadDim > 0:
+		return c.HeadDim
+	case c.AttentionHeadDim > 0:
+		return c.AttentionHeadDim
+	case c.NumAttentionHeads == 0:
+		return 0
+	}
+	return c.HiddenSize / c.NumAttentionHeads
+}
+
+// usesLatentAttention reports whether the model caches an MLA latent.
+// Only MLA models (DeepSeek-V2/V3 and derivatives) define kv_lora_rank.
+func (c *ModelConfig) usesLatentAttention() bool {
+	return c.KVLoraRank > 0
+}
+
+// usesSlidingWindow reports whether any layer is limited to the sliding window
+func (c *ModelConfig) usesSlidingWindow() bool {
+	if c.SlidingWindow <= 0 {
+		return false
+	}
+	if c.UseSlidingWindow != nil {
+		return *c.UseSlidingWindow
+	}
+	return !slidingWindowOptIn[c.family()]
+}
+
+// layerKinds returns the attention kind of every decoder layer
+func (c *ModelConfig) layerKinds() []attentionKind {
+	kinds := make([]attentionKind, c.NumHiddenLayers)
+
+	switch {
+	case c.hasStateSpaceLayers():
+		c.stateSpaceLayerKinds(kinds)
+
+	case c.usesLatentAttention():
+		for i := range kinds {
+			kinds[i] = attentionLatent
+		}
+
+	case len(c.LayerTypes) == c.NumHiddenLayers:
+		for i, layerType := range c.LayerTypes {
+			switch {
+			case layerType == "sliding_attention" && c.SlidingWindow > 0:
+				kinds[i] = attentionSliding
+			case layerType == "mamba":
+				kinds[i] = attentionSSM
+			}
+		}
+
+	case c.usesSlidingWindow():
+		pattern := c.SlidingWindowPattern
+		if pattern == 0 {
+			pattern = slidingWindowPatterns[c.family()]
+		}
+		for i := range kinds {
+			switch {
+			case pattern > 0 && (i+1)%pattern == 0:
+				kinds[i] = attentionFull
+			case c.MaxWindowLayers > 0 && i < c.MaxWindowLayers && slidingWindowOptIn[c.family()]:
+				kinds[i] = attentionFull
+			default:
+				kinds[i] = attentionSliding
+			}
+		}
+	}
+
+	for _, layer := range c.CrossAttentionLayers {
+		if layer >= 0 && layer < len(kinds) {
+			kinds[layer] = attentionCross
+		}
+	}
+
+	return kinds
+}
+
+// kvElementsPerToken returns the number of cached elements per token for a
+// layer, given the KV heads held by one tensor-parallel rank
+func (c *ModelConfig) kvElementsPerToken(kind attentionKind, kvHeads int) float64 {
+	if kind == attentionLatent {
+		// The compressed KV latent and the decoupled RoPE key are shared by all heads
+		return float64(c.KVLoraRank + c.QKRopeHeadDim)
+	}
+	// One key and one value vector per KV head
+	return float64(2 * kvHeads * c.headDim())
+}
+
+// cachedTokens returns how many tokens of a sequence a layer of the given kind
+// keeps in cache
+func (c *ModelConfig) cachedTokens(kind attentionKind, seq sequenceTokens) int {
+	switch {
+	case kind == attentionCross:
+		return seq.cross
+	case kind == attentionSliding && c.SlidingWindow < seq.self:
+		return c.SlidingWindow
+	}
+	return seq.self
+}
+
+// kvHeadsPerRank returns the KV heads each tensor-parallel rank caches. KV heads
+// can't be split below one per rank, so GQA models replicate them when
+// tensorParallel exceeds num_key_value_heads.
+func (c *ModelConfig) kvHeadsPerRank(tensorParallel int) int {
+	if tensorParallel <= 1 {
+		return c.NumKeyValueHeads
+	}
+	return max(1, (c.NumKeyValueHeads+tensorParallel-1)/tensorParallel)
+}
+
+// kvCacheElements returns the number of cached keys and values for one sequence
+func (c *ModelConfig) kvCacheElements(seq sequenceTokens) float64 {
+	return c.kvCacheElementsPerRank(seq, 0, c.NumHiddenLayers, 1)
+}
+
+// kvCacheElementsPerRank returns the number of cached keys and values for one
+// sequence on a tensor-parallel rank holding layers [firstLayer, lastLayer).
+// Recurrent states are counted by stateElementsPerRank.
+func (c *ModelConfig) kvCacheElementsPerRank(seq sequenceTokens, firstLayer, lastLayer, tensorParallel int) float64 {
+	kvHeads := c.kvHeadsPerRank(tensorParallel)
+	kinds := c.layerKinds()
+
+	var elements float64
+	for _, kind := range kinds[firstLayer:lastLayer] {
+		if kind == attentionSSM {
+			continue
+		}
+		elements += float64(c.cachedTokens(kind, seq)) * c.kvElementsPerToken(kind, kvHeads)
+	}
+	return elements + c.crossAttentionElements(seq, firstLayer, lastLayer, kvHeads)
+}
+
+// AttentionSummary describes how the model's layers cache keys and values
+func (c *ModelConfig) AttentionSummary() string {
+	counts := make(map[attentionKind]int)
+	for _, kind := range c.layerKinds() {
+		counts[kind]++
+	}
+
+	var parts []string
+	if n := counts[attentionLatent]; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d latent layers (MLA, rank %d+%d)", n, c.KVLoraRank, c.QKRopeHeadDim))
+	}
+	if n := counts[attentionSliding]; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d sliding-window layers (%d tokens)", n, c.SlidingWindow))
+	}
+	if n := counts[attentionFull]; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d full-attention layers (%d KV heads x %d dim)", n, c.NumKeyValueHeads, c.headDim()))
+	}
+	if n := counts[attentionHybrid]; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d hybrid attention and state-space layers (%d KV heads x %d dim)",
+			n, c.NumKeyValueHeads, c.headDim()))
+	}
+	if n := counts[attentionSSM]; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d state-space layers (%.0f-element recurrent state per sequence)",
+			n, c.ssmStateElements(1)))
+	}
+	if n := counts[attentionCross]; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d cross-attention layers (image tokens only)", n))
+	}
+	if c.IsEncoderDecoder {
+		parts = append(parts, c.encoderDecoderSummary())
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,57 @@
+// internal/calculator/breakdown.go
+
+package calculator
+
+// MemoryBreakdown itemizes the memory required on a single GPU in GB
+type MemoryBreakdown struct {
+	Label         string // Which GPU(s) the breakdown applies to
+	GPUs          int    // Number of identical GPUs sharing this breakdown
+	WeightsGB     float64
+	OverheadGB    float64
+	ActivationsGB float64 // Peak prefill activations, or those stored for the backward pass in training
+	KVCacheGB     float64
+	GradientsGB   float64 // Training only
+	OptimizerGB   float64 // Training only: optimizer states, including fp32 master weights
+}
+
+// NewMemoryBreakdown itemizes a GPU holding the given weights, activations and
+// KV cache, adding the overhead of the model
+func NewMemoryBreakdown(label string, gpus int, weightsGB, activationsGB, kvCacheGB float64, overhead OverheadModel) MemoryBreakdown {
+	return MemoryBreakdown{
+		Label:         label,
+		GPUs:          gpus,
+		WeightsGB:     round(weightsGB, 2),
+		OverheadGB:    overhead.PerGPU(weightsGB),
+		ActivationsGB: round(activationsGB, 2),
+		KVCacheGB:     round(kvCacheGB, 2),
+	}
+}
+
+// gpuBreakdowns returns one breakdown per pipeline stage, or a single breakdown
+// when the model runs on one GPU
+func gpuBreakdowns(params ParallelParams) ([]MemoryBreakdown, error) {
+	if params.Parallel.GPUs() == 1 {
+		weights, kv := params.draftShare(params.WeightsGB, params.KVCacheGB)
+		return []MemoryBreakdown{
+			NewMemoryBreakdown("GPU", 1, weights, params.ActivationsGB, kv, params.Overhead),
+		}, nil
+	}
+	return CalculateParallelMemory(params)
+}
+
+// Total returns the total memory of the breakdown
+func (b MemoryBreakdown) Total() float64 {
+	return round(b.WeightsGB+b.OverheadGB+b.ActivationsGB+b.KVCacheGB+b.GradientsGB+b.OptimizerGB, 2)
+}
+
+// PeakBreakdown returns the breakdown with the highest total, which decides
+// whether a multi-GPU deployment fits
+func PeakBreakdown(breakdowns []MemoryBreakdown) MemoryBreakdown {
+	var peak MemoryBreakdown
+	for _, b := range breakdowns {
+		if b.Total() > peak.Total() {
+			peak = b
+		}
+	}
+	return peak
+}
@@ -0,0 +1,175 @@
+// internal/calculator/config.go
+
+package calculator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ModelConfig represents the relevant fields from config.json
+type ModelConfig struct {
+	ModelType     string   `json:"model_type"`
+	Architectures []string `json:"architectures"`
+
+	// Decoder dimensions
+	HiddenSize        int    `json:"hidden_size"`
+	NumAttentionHeads int    `json:"num_attention_heads"`
+	NumHiddenLayers   int    `json:"num_hidden_layers"`
+	NumKeyValueHeads  int    `json:"num_key_value_heads"`
+	HeadDim           int    `json:"head_dim"`
+	IntermediateSize  int    `json:"intermediate_size"`
+	VocabSize         int    `json:"vocab_size"`
+	FeedForwardProj   string `json:"feed_forward_proj"` // T5 MLP variant, gated-gelu for gated MLPs
+
+	// Context length, see ContextLimits
+	MaxPositionEmbeddings int          `json:"max_position_embeddings"`
+	RopeScaling           *RopeScaling `json:"rope_scaling"`
+
+	TieWordEmbeddings *bool `json:"tie_word_embeddings"` // See tiedEmbeddings
+
+	// Sliding window attention
+	SlidingWindow        int      `json:"sliding_window"`
+	UseSlidingWindow     *bool    `json:"use_sliding_window"`
+	MaxWindowLayers      int      `json:"max_window_layers"`
+	SlidingWindowPattern int      `json:"sliding_window_pattern"`
+	LayerTypes           []string `json:"layer_types"`
+
+	// Multi-head latent attention
+	KVLoraRank    int `json:"kv_lora_rank"`
+	QKRopeHeadDim int `json:"qk_rope_head_dim"`
+	QLoraRank     int `json:"q_lora_rank"`
+	QKNopeHeadDim int `json:"qk_nope_head_dim"`
+	VHeadDim      int `json:"v_head_dim"`
+
+	// Mixture of Experts
+	NumLocalExperts              int `json:"num_local_experts"`
+	NumExperts                   int `json:"num_experts"`
+	NRoutedExperts               int `json:"n_routed_experts"`
+	NumExpertsPerTok             int `json:"num_experts_per_tok"`
+	MoEIntermediateSize          int `json:"moe_intermediate_size"`
+	NSharedExperts               int `json:"n_shared_experts"`
+	SharedExpertIntermediateSize int `json:"shared_expert_intermediate_size"`
+	FirstKDenseReplace           int `json:"first_k_dense_replace"`
+	DecoderSparseStep            int `json:"decoder_sparse_step"`
+	ExpertLayerPeriod            int `json:"expert_layer_period"` // Jamba
+	ExpertLayerOffset            int `json:"expert_layer_offset"`
+
+	// Speculative decoding heads
+	NumNextnPredictLayers int `json:"num_nextn_predict_layers"`
+	MedusaNumHeads        int `json:"medusa_num_heads"`
+	MedusaNumLayers       int `json:"medusa_num_layers"`
+
+	// Multimodal models keep the decoder under text_config (llm_config for InternVL)
+	TextConfig           *ModelConfig     `json:"text_config"`
+	LLMConfig            *ModelConfig     `json:"llm_config"`
+	VisionConfig         *VisionConfig    `json:"vision_config"`
+	PerceiverConfig      *PerceiverConfig `json:"perceiver_config"`
+	CrossAttentionLayers []int            `json:"cross_attention_layers"`
+	MMTokensPerImage     int              `json:"mm_tokens_per_image"`
+	ImageSeqLength       int              `json:"image_seq_length"`
+	ScaleFactor          int              `json:"scale_factor"`
+	DownsampleRatio      float64          `json:"downsample_ratio"`
+	MaxDynamicPatch      int              `json:"max_dynamic_patch"`
+	MultimodalType       string           `json:"-"` // model_type of the multimodal wrapper around a nested decoder
+
+	// Encoder-decoder models (T5 and BART/Whisper field names)
+	IsEncoderDecoder      bool `json:"is_encoder_decoder"`
+	DModel                int  `json:"d_model"`
+	DKV                   int  `json:"d_kv"`
+	DFF                   int  `json:"d_ff"`
+	NumHeads              int  `json:"num_heads"`
+	NumLayers             int  `json:"num_layers"`
+	NumDecoderLayers      int  `json:"num_decoder_layers"`
+	EncoderLayers         int  `json:"encoder_layers"`
+	DecoderLayers         int  `json:"decoder_layers"`
+	DecoderAttentionHeads int  `json:"decoder_attention_heads"`
+	DecoderFFNDim         int  `json:"decoder_ffn_dim"`
+	MaxSourcePositions    int  `json:"max_source_positions"`
+	MaxTargetPositions    int  `json:"max_target_positions"`
+
+	// State-space and hybrid models (Mamba, Mamba-2, RWKV, Jamba, Zamba)
+	StateSize           int      `json:"state_size"`
+	DState              int      `json:"d_state"`
+	MambaDState         int      `json:"mamba_d_state"`
+	ConvKernel          int      `json:"conv_kernel"`
+	DConv               int      `json:"d_conv"`
+	MambaDConv          int      `json:"mamba_d_conv"`
+	Expand              float64  `json:"expand"`
+	MambaExpand         float64  `json:"mamba_expand"`
+	NGroups             int      `json:"n_groups"`
+	MambaNGroups        int      `json:"mamba_ngroups"`
+	LayersBlockType     []string `json:"layers_block_type"`
+	AttnLayerPeriod     int      `json:"attn_layer_period"`
+	AttnLayerOffset     int      `json:"attn_layer_offset"`
+	AttentionHeadDim    int      `json:"attention_head_dim"`
+	AttentionHiddenSize int      `json:"attention_hidden_size"`
+	HeadSize            int      `json:"head_size"` // RWKV-5 and later
+
+	// Checkpoint storage
+	TorchDtype         string              `json:"torch_dtype"`
+	QuantizationConfig *QuantizationConfig `json:"quantization_config"`
+}
+
+// FetchModelConfig retrieves the model's configuration from HuggingFace
+func FetchModelConfig(modelID string) (*ModelConfig, error) {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	url := fmt.Sprintf("https://huggingface.co/%s/raw/main/config.json", modelID)
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch model config: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		return nil, fmt.Errorf("\n%s", string(body))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config response: %w", err)
+	}
+
+	return ParseModelConfig(body)
+}
+
+// ParseModelConfig parses config.json and normalizes it to the decoder
+// dimensions the calculations use
+func ParseModelConfig(data []byte) (*ModelConfig, error) {
+	var config ModelConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	var raw rawConfig
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	// Multimodal models nest the decoder dimensions
+	if config.liftTextConfig() {
+		raw = raw.textConfig()
+	}
+
+	// Families that don't use Llama-style field names
+	config.applyDialect(raw)
+
+	// Encoder-decoder models name their dimensions differently
+	config.resolveEncoderDecoder()
+
+	// Handle models that don't specify num_key_value_heads
+	if config.NumKeyValueHeads == 0 {
+		config.NumKeyValueHeads = config.NumAttentionHeads
+	}
+
+	return &config, nil
+}
@@ -0,0 +1,129 @@
+// internal/calculator/context.go
+
+package calculator
+
+import "fmt"
+
+// RopeScaling represents the rope_scaling entry of config.json
+type RopeScaling struct {
+	Type                          string  `json:"type"`
+	RopeType                      string  `json:"rope_type"` // Newer configs name the type rope_type
+	Factor                        float64 `json:"factor"`
+	OriginalMaxPositionEmbeddings int     `json:"original_max_position_embeddings"`
+}
+
+// contextExtendingRopeTypes lists the RoPE scaling types that stretch the
+// trained context by their factor
+var contextExtendingRopeTypes = map[string]bool{
+	"linear":  true,
+	"dynamic": true,
+	"yarn":    true,
+	"llama3":  true,
+}
+
+// scalingType returns the RoPE scaling type under either name
+func (r *RopeScaling) scalingType() string {
+	if r.RopeType != "" {
+		return r.RopeType
+	}
+	return r.Type
+}
+
+// ContextLimits describes the context a model supports
+type ContextLimits struct {
+	Trained       int     // original_max_position_embeddings under RoPE scaling, else max_position_embeddings
+	Max           int     // Longest supported context, 0 if unknown
+	ScalingType   string  // RoPE scaling type that extends Trained to Max, if any
+	ScalingFactor float64 // RoPE scaling factor
+	SlidingWindow int     // Tokens every layer attends to when no layer is global, 0 otherwise
+}
+
+// String describes the supported context, e.g. "128k tokens (yarn 4x of 32k)"
+func (l ContextLimits) String() string {
+	if l.Max == 0 {
+		return "unknown"
+	}
+	s := formatTokens(l.Max) + " tokens"
+	if l.ScalingType != "" {
+		s += fmt.Sprintf(" (%s %gx of %s)", l.ScalingType, l.ScalingFactor, formatTokens(l.Trained))
+	}
+	return s
+}
+
+// formatTokens formats a token count in k or M of 1024 tokens when it divides evenly
+func formatTokens(tokens int) string {
+	switch {
+	case tokens >= 1<<20 && tokens%(1<<20) == 0:
+		return fmt.Sprintf("%dM", tokens>>20)
+	case tokens >= 1<<10 && tokens%(1<<10) == 0:
+		return fmt.Sprintf("%dk", tokens>>10)
+	}
+	return fmt.Sprint(tokens)
+}
+
+// ContextLimits returns the context the model supports. Linear, dynamic NTK,
+// YaRN and Llama-3 RoPE scaling stretch original_max_position_embeddings by
+// their factor; configs such as Llama-3.1 and DeepSeek-V3 already count the
+// scaled context in max_position_embeddings, while configs that add
+// rope_scaling to a checkpoint, as Qwen2.5 with YaRN, don't. Without
+// original_max_position_embeddings, as in Gemma-3, max_position_embeddings is
+// taken to be the scaled context.
+func (c *ModelConfig) ContextLimits() ContextLimits {
+	limits := ContextLimits{Trained: c.MaxPositionEmbeddings, Max: c.MaxPositionEmbeddings}
+
+	r := c.RopeScaling
+	if r != nil && r.Factor > 1 && r.OriginalMaxPositionEmbeddings > 0 && contextExtendingRopeTypes[r.scalingType()] {
+		limits.Trained = r.OriginalMaxPositionEmbeddings
+		limits.Max = max(limits.Max, int(float64(limits.Trained)*r.Factor))
+		limits.ScalingType = r.scalingType()
+		limits.ScalingFactor = r.Factor
+	}
+
+	if c.NumHiddenLayers > 0 && c.usesSlidingWindow() {
+		limits.SlidingWindow = c.SlidingWindow
+		for _, kind := range c.layerKinds() {
+			if kind != attentionSliding {
+				limits.SlidingWindow = 0
+				break
+			}
+		}
+	}
+	return limits
+}
+
+// ErrContextTooLong represents a requested context beyond what the model supports
+type ErrContextTooLong struct {
+	Sequence  string // The sequence that is too long, e.g. "context" or "encoder input"
+	Requested int
+	Limits    ContextLimits
+}
+
+func (e ErrContextTooLong) Error() string {
+	return fmt.Sprintf("%s of %d tokens exceeds the %s the model supports",
+		e.Sequence, e.Requested, e.Limits)
+}
+
+// CheckContextLength returns ErrContextTooLong when a sequence is longer than
+// the model supports: the context with its image tokens, or the decoder output
+// and encoder input of encoder-decoder models. Models with unknown limits pass.
+func (p KVCacheParams) CheckContextLength() error {
+	if p.Config == nil {
+		return nil
+	}
+	seq := p.sequence()
+	limits := p.Config.ContextLimits()
+
+	name := "context"
+	if p.Config.IsEncoderDecoder {
+		name = "decoder output"
+	}
+	if limits.Max > 0 && seq.self > limits.Max {
+		return ErrContextTooLong{name, seq.self, limits}
+	}
+
+	if p.Config.IsEncoderDecoder && p.Config.MaxSourcePositions > 0 && seq.cross > p.Config.MaxSourcePositions {
+		encoder := ContextLimits{Trained: p.Config.MaxSourcePositions, Max: p.Config.MaxSourcePositions}
+		return ErrContextTooLong{"encoder input", seq.cross, encoder}
+	}
+	return nil
+}
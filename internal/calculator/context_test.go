@@ -0,0 +1,122 @@
+// internal/calculator/context_test.go
+
+package calculator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestContextLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   ContextLimits
+		str    string
+	}{
+		{
+			name:   "no scaling",
+			config: `{"model_type": "llama", "max_position_embeddings": 8192}`,
+			want:   ContextLimits{Trained: 8192, Max: 8192},
+			str:    "8k tokens",
+		},
+		{
+			name: "linear",
+			config: `{"model_type": "llama", "max_position_embeddings": 4096,
+				"rope_scaling": {"type": "linear", "factor": 2.0, "original_max_position_embeddings": 4096}}`,
+			want: ContextLimits{Trained: 4096, Max: 8192, ScalingType: "linear", ScalingFactor: 2},
+			str:  "8k tokens (linear 2x of 4k)",
+		},
+		{
+			// google/gemma-3-27b-it counts the scaled context in max_position_embeddings
+			name: "linear without original_max_position_embeddings",
+			config: `{"model_type": "gemma3_text", "max_position_embeddings": 131072,
+				"rope_scaling": {"rope_type": "linear", "factor": 8.0}}`,
+			want: ContextLimits{Trained: 131072, Max: 131072},
+			str:  "128k tokens",
+		},
+		{
+			name: "dynamic NTK without original_max_position_embeddings",
+			config: `{"model_type": "llama", "max_position_embeddings": 2048,
+				"rope_scaling": {"type": "dynamic", "factor": 4.0}}`,
+			want: ContextLimits{Trained: 2048, Max: 2048},
+			str:  "2k tokens",
+		},
+		{
+			// Qwen/Qwen2.5-7B-Instruct with the YaRN rope_scaling its model card adds
+			name: "yarn added to the checkpoint",
+			config: `{"model_type": "qwen2", "max_position_embeddings": 32768,
+				"rope_scaling": {"type": "yarn", "factor": 4.0, "original_max_position_embeddings": 32768}}`,
+			want: ContextLimits{Trained: 32768, Max: 131072, ScalingType: "yarn", ScalingFactor: 4},
+			str:  "128k tokens (yarn 4x of 32k)",
+		},
+		{
+			name: "llama3 already counted in max_position_embeddings",
+			config: `{"model_type": "llama", "max_position_embeddings": 131072,
+				"rope_scaling": {"rope_type": "llama3", "factor": 8.0, "original_max_position_embeddings": 8192}}`,
+			want: ContextLimits{Trained: 8192, Max: 131072, ScalingType: "llama3", ScalingFactor: 8},
+			str:  "128k tokens (llama3 8x of 8k)",
+		},
+		{
+			name: "scaling that doesn't extend the context",
+			config: `{"model_type": "phi3", "max_position_embeddings": 4096,
+				"rope_scaling": {"type": "longrope", "factor": 32.0}}`,
+			want: ContextLimits{Trained: 4096, Max: 4096},
+			str:  "4k tokens",
+		},
+		{
+			name: "sliding window on every layer",
+			config: `{"model_type": "mistral", "hidden_size": 4096, "num_hidden_layers": 32,
+				"num_attention_heads": 32, "max_position_embeddings": 32768, "sliding_window": 4096}`,
+			want: ContextLimits{Trained: 32768, Max: 32768, SlidingWindow: 4096},
+			str:  "32k tokens",
+		},
+		{
+			name: "sliding window with global layers",
+			config: `{"model_type": "gemma2", "hidden_size": 3584, "num_hidden_layers": 42,
+				"num_attention_heads": 16, "max_position_embeddings": 8192, "sliding_window": 4096}`,
+			want: ContextLimits{Trained: 8192, Max: 8192},
+			str:  "8k tokens",
+		},
+		{
+			name:   "unknown",
+			config: `{"model_type": "llama"}`,
+			want:   ContextLimits{},
+			str:    "unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mustParseConfig(t, tt.config).ContextLimits()
+			if got != tt.want {
+				t.Errorf("ContextLimits() = %+v, want %+v", got, tt.want)
+			}
+			if got.String() != tt.str {
+				t.Errorf("String() = %q, want %q", got.String(), tt.str)
+			}
+		})
+	}
+}
+
+func TestCheckContextLength(t *testing.T) {
+	config := mustParseConfig(t, `{"model_type": "llama", "hidden_size": 4096, "num_hidden_layers": 32,
+		"num_attention_heads": 32, "max_position_embeddings": 4096,
+		"rope_scaling": {"type": "linear", "factor": 2.0, "original_max_position_embeddings": 4096}}`)
+
+	tests := []struct {
+		context, images int
+		wantErr         bool
+	}{
+		{8192, 0, false},
+		{8193, 0, true},
+		{6000, 2500, true}, // Image tokens join the context
+	}
+	for _, tt := range tests {
+		err := KVCacheParams{Users: 1, ContextLength: tt.context, ImageTokens: tt.images, Config: config}.CheckContextLength()
+		var tooLong ErrContextTooLong
+		if got := errors.As(err, &tooLong); got != tt.wantErr {
+			t.Errorf("CheckContextLength(%d + %d images) = %v, want error %v", tt.context, tt.images, err, tt.wantErr)
+		}
+	}
+}
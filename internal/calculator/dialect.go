@@ -0,0 +1,260 @@
+// internal/calculator/dialect.go
+
+package calculator
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// rawConfig is config.json as untyped JSON, for fields a family names differently
+type rawConfig map[string]json.RawMessage
+
+// value returns the value at a key, or at a dotted path such as
+// attn_config.kv_n_heads into nested objects. Null counts as absent.
+func (r rawConfig) value(path string) (json.RawMessage, bool) {
+	current := r
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		v, ok := current[key]
+		if !ok || string(v) == "null" {
+			return nil, false
+		}
+		if i == len(keys)-1 {
+			return v, true
+		}
+		if err := json.Unmarshal(v, &current); err != nil {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// has reports whether a key is present and not null
+func (r rawConfig) has(path string) bool {
+	_, ok := r.value(path)
+	return ok
+}
+
+// number returns a numeric value
+func (r rawConfig) number(path string) (float64, bool) {
+	v, ok := r.value(path)
+	if !ok {
+		return 0, false
+	}
+	var n float64
+	if err := json.Unmarshal(v, &n); err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// flag returns a boolean value, false when absent
+func (r rawConfig) flag(path string) bool {
+	v, ok := r.value(path)
+	if !ok {
+		return false
+	}
+	var b bool
+	return json.Unmarshal(v, &b) == nil && b
+}
+
+// textConfig returns the nested decoder config of a multimodal model
+func (r rawConfig) textConfig() rawConfig {
+	for _, key := range []string{"text_config", "llm_config"} {
+		if v, ok := r.value(key); ok {
+			var nested rawConfig
+			if json.Unmarshal(v, &nested) == nil {
+				return nested
+			}
+		}
+	}
+	return rawConfig{}
+}
+
+// configDialect maps the config.json field names of a model family onto the
+// canonical ModelConfig fields. Each list holds keys in order of preference,
+// dotted keys reach into nested objects. Llama-style names always win, so a
+// dialect only lists the family's own names.
+type configDialect struct {
+	hiddenSize       []string
+	numLayers        []string
+	numHeads         []string
+	numKVHeads       []string
+	headDim          []string
+	intermediateSize []string
+	vocabSize        []string
+	maxPositions     []string
+
+	mlpRatio         float64                       // intermediate_size as a multiple of hidden_size when no key is set
+	untiedEmbeddings bool                          // The family's config class defaults tie_word_embeddings to false, see tiedEmbeddings
+	fixup            func(*ModelConfig, rawConfig) // Family rules the field names can't express
+}
+
+// GPT-2 style names, also used by GPT-J and StarCoder
+var gpt2Dialect = configDialect{
+	hiddenSize:       []string{"n_embd"},
+	numLayers:        []string{"n_layer"},
+	numHeads:         []string{"n_head"},
+	intermediateSize: []string{"n_inner"},
+	maxPositions:     []string{"n_positions"},
+	mlpRatio:         4,
+}
+
+// Falcon names, including the RefinedWeb configs of the original checkpoints
+var falconDialect = configDialect{
+	hiddenSize:       []string{"n_embed"},
+	numLayers:        []string{"n_layer"},
+	numHeads:         []string{"n_head"},
+	numKVHeads:       []string{"num_kv_heads", "n_head_kv"},
+	intermediateSize: []string{"ffn_hidden_size"},
+	mlpRatio:         4,
+	fixup: func(c *ModelConfig, raw rawConfig) {
+		// Falcon-7B shares one KV head, the new decoder architecture uses num_kv_heads
+		if raw.flag("multi_query") && !raw.flag("new_decoder_architecture") {
+			c.NumKeyValueHeads = 1
+		}
+	},
+}
+
+// GPT-2 names with an untied LM head, for GPT-J and the remote-code Phi-1 and Phi-1.5
+var gptjDialect = configDialect{
+	hiddenSize:       gpt2Dialect.hiddenSize,
+	numLayers:        gpt2Dialect.numLayers,
+	numHeads:         gpt2Dialect.numHeads,
+	intermediateSize: gpt2Dialect.intermediateSize,
+	maxPositions:     gpt2Dialect.maxPositions,
+	mlpRatio:         4,
+	untiedEmbeddings: true,
+}
+
+// configDialects registers the dialects by model_type. Families with
+// Llama-style names only need an entry for differing defaults, such as the
+// untied LM head of Llama and its descendants; every other family ties it, as
+// transformers' PretrainedConfig does.
+var configDialects = map[string]configDialect{
+	"llama":             {untiedEmbeddings: true},
+	"mllama_text_model": {untiedEmbeddings: true},
+	"mistral":           {untiedEmbeddings: true},
+	"mixtral":           {untiedEmbeddings: true},
+	"qwen2":             {untiedEmbeddings: true},
+	"qwen2_moe":         {untiedEmbeddings: true},
+	"qwen3":             {untiedEmbeddings: true},
+	"qwen3_moe":         {untiedEmbeddings: true},
+	"phi3":              {untiedEmbeddings: true},
+	"phimoe":            {untiedEmbeddings: true},
+	"deepseek_v2":       {untiedEmbeddings: true},
+	"deepseek_v3":       {untiedEmbeddings: true},
+	"glm4":              {untiedEmbeddings: true},
+	"glm4_moe":          {untiedEmbeddings: true},
+	"gpt_oss":           {untiedEmbeddings: true},
+	"jamba":             {untiedEmbeddings: true},
+	"olmo":              {untiedEmbeddings: true},
+	"olmo2":             {untiedEmbeddings: true},
+	"olmoe":             {untiedEmbeddings: true},
+	"granite":           {untiedEmbeddings: true},
+	"granitemoe":        {untiedEmbeddings: true},
+	"internlm2":         {untiedEmbeddings: true},
+	"stablelm":          {untiedEmbeddings: true},
+	"dbrx":              {untiedEmbeddings: true},
+	"nemotron":          {untiedEmbeddings: true},
+	"rwkv":              {untiedEmbeddings: true},
+
+	"gpt2": gpt2Dialect,
+	"gptj": gptjDialect,
+	"gpt_bigcode": {
+		hiddenSize:       gpt2Dialect.hiddenSize,
+		numLayers:        gpt2Dialect.numLayers,
+		numHeads:         gpt2Dialect.numHeads,
+		intermediateSize: gpt2Dialect.intermediateSize,
+		maxPositions:     gpt2Dialect.maxPositions,
+		mlpRatio:         4,
+		fixup: func(c *ModelConfig, raw rawConfig) {
+			// StarCoder uses multi-query attention unless disabled
+			if !raw.has("multi_query") || raw.flag("multi_query") {
+				c.NumKeyValueHeads = 1
+			}
+		},
+	},
+	"falcon":          falconDialect,
+	"refinedweb":      falconDialect,
+	"refinedwebmodel": falconDialect,
+	"mpt": {
+		hiddenSize:       []string{"d_model"},
+		numLayers:        []string{"n_layers"},
+		numHeads:         []string{"n_heads"},
+		numKVHeads:       []string{"attn_config.kv_n_heads"},
+		intermediateSize: []string{"ffn_config.ffn_hidden_size"},
+		maxPositions:     []string{"max_seq_len"},
+		mlpRatio:         4,
+		fixup: func(c *ModelConfig, raw rawConfig) {
+			if ratio, ok := raw.number("expansion_ratio"); ok && c.IntermediateSize == 0 {
+				c.IntermediateSize = int(ratio * float64(c.HiddenSize))
+			}
+		},
+	},
+	"bloom": {
+		hiddenSize: []string{"n_embed"},
+		numLayers:  []string{"n_layer"},
+		numHeads:   []string{"n_head"},
+		mlpRatio:   4,
+	},
+	"phi":                  {mlpRatio: 4, untiedEmbeddings: true},
+	"phi-msft":             gptjDialect,
+	"mixformer-sequential": gptjDialect,
+	"gpt_neox":             {mlpRatio: 4, untiedEmbeddings: true},
+	"opt": {
+		intermediateSize: []string{"ffn_dim"},
+	},
+	"chatglm": {
+		untiedEmbeddings: true,
+		numLayers:        []string{"num_layers"},
+		headDim:          []string{"kv_channels"},
+		intermediateSize: []string{"ffn_hidden_size"},
+		vocabSize:        []string{"padded_vocab_size"},
+		maxPositions:     []string{"seq_length"},
+		fixup: func(c *ModelConfig, raw rawConfig) {
+			if groups, ok := raw.number("multi_query_group_num"); ok && raw.flag("multi_query_attention") {
+				c.NumKeyValueHeads = int(groups)
+			}
+		},
+	},
+}
+
+// applyDialect fills the canonical fields from the family's own field names
+func (c *ModelConfig) applyDialect(raw rawConfig) {
+	dialect, ok := configDialects[c.family()]
+	if !ok {
+		return
+	}
+
+	for _, field := range []struct {
+		value *int
+		keys  []string
+	}{
+		{&c.HiddenSize, dialect.hiddenSize},
+		{&c.NumHiddenLayers, dialect.numLayers},
+		{&c.NumAttentionHeads, dialect.numHeads},
+		{&c.NumKeyValueHeads, dialect.numKVHeads},
+		{&c.HeadDim, dialect.headDim},
+		{&c.IntermediateSize, dialect.intermediateSize},
+		{&c.VocabSize, dialect.vocabSize},
+		{&c.MaxPositionEmbeddings, dialect.maxPositions},
+	} {
+		for _, key := range field.keys {
+			if *field.value != 0 {
+				break
+			}
+			if n, ok := raw.number(key); ok {
+				*field.value = int(n)
+			}
+		}
+	}
+
+	if dialect.fixup != nil {
+		dialect.fixup(c, raw)
+	}
+	if c.IntermediateSize == 0 && dialect.mlpRatio > 0 {
+		c.IntermediateSize = int(dialect.mlpRatio * float64(c.HiddenSize))
+	}
+}
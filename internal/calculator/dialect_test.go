@@ -0,0 +1,116 @@
+// internal/calculator/dialect_test.go
+
+package calculator
+
+import "testing"
+
+func TestParseModelConfigDialects(t *testing.T) {
+	// dims are the canonical fields a family's own names normalize to
+	type dims struct {
+		hidden, layers, heads, kvHeads, intermediate, vocab, positions int
+		tied                                                           bool
+	}
+	tests := []struct {
+		name   string
+		config string
+		want   dims
+	}{
+		{
+			name: "gpt2",
+			config: `{"model_type": "gpt2", "n_embd": 768, "n_layer": 12, "n_head": 12,
+				"n_inner": null, "n_positions": 1024, "vocab_size": 50257}`,
+			want: dims{768, 12, 12, 12, 3072, 50257, 1024, true},
+		},
+		{
+			name: "falcon-7b multi-query",
+			config: `{"model_type": "falcon", "hidden_size": 4544, "num_hidden_layers": 32,
+				"num_attention_heads": 71, "multi_query": true, "new_decoder_architecture": false,
+				"vocab_size": 65024}`,
+			want: dims{4544, 32, 71, 1, 18176, 65024, 0, true},
+		},
+		{
+			name: "falcon-40b new decoder architecture",
+			config: `{"model_type": "falcon", "hidden_size": 8192, "num_hidden_layers": 60,
+				"num_attention_heads": 128, "num_kv_heads": 8, "multi_query": true,
+				"new_decoder_architecture": true, "vocab_size": 65024}`,
+			want: dims{8192, 60, 128, 8, 32768, 65024, 0, true},
+		},
+		{
+			name: "RefinedWeb",
+			config: `{"model_type": "RefinedWeb", "hidden_size": 8192, "n_layer": 60, "n_head": 128,
+				"n_head_kv": 8, "new_decoder_architecture": true, "vocab_size": 65024}`,
+			want: dims{8192, 60, 128, 8, 32768, 65024, 0, true},
+		},
+		{
+			name: "mpt",
+			config: `{"model_type": "mpt", "d_model": 4096, "n_layers": 32, "n_heads": 32,
+				"expansion_ratio": 4, "max_seq_len": 2048, "vocab_size": 50432,
+				"attn_config": {"kv_n_heads": null}}`,
+			want: dims{4096, 32, 32, 32, 16384, 50432, 2048, true},
+		},
+		{
+			name: "bloom",
+			config: `{"model_type": "bloom", "hidden_size": 1024, "n_layer": 24, "n_head": 16,
+				"vocab_size": 250880}`,
+			want: dims{1024, 24, 16, 16, 4096, 250880, 0, true},
+		},
+		{
+			name: "starcoder",
+			config: `{"model_type": "gpt_bigcode", "n_embd": 6144, "n_layer": 40, "n_head": 48,
+				"n_inner": 24576, "n_positions": 8192, "vocab_size": 49152}`,
+			want: dims{6144, 40, 48, 1, 24576, 49152, 8192, true},
+		},
+		{
+			name: "phi-1.5 remote code",
+			config: `{"model_type": "mixformer-sequential", "n_embd": 2048, "n_layer": 24,
+				"n_head": 32, "n_positions": 2048, "vocab_size": 51200}`,
+			want: dims{2048, 24, 32, 32, 8192, 51200, 2048, false},
+		},
+		{
+			name: "gpt-neox without intermediate_size",
+			config: `{"model_type": "gpt_neox", "hidden_size": 6144, "num_hidden_layers": 44,
+				"num_attention_heads": 64, "vocab_size": 50432, "tie_word_embeddings": false}`,
+			want: dims{6144, 44, 64, 64, 24576, 50432, 0, false},
+		},
+		{
+			name: "chatglm3",
+			config: `{"model_type": "chatglm", "hidden_size": 4096, "num_layers": 28,
+				"num_attention_heads": 32, "kv_channels": 128, "ffn_hidden_size": 13696,
+				"multi_query_attention": true, "multi_query_group_num": 2,
+				"padded_vocab_size": 65024, "seq_length": 8192}`,
+			want: dims{4096, 28, 32, 2, 13696, 65024, 8192, false},
+		},
+		{
+			// google/gemma-7b leaves tie_word_embeddings to transformers' default
+			name: "gemma ties by default",
+			config: `{"model_type": "gemma", "hidden_size": 3072, "num_hidden_layers": 28,
+				"num_attention_heads": 16, "num_key_value_heads": 16, "head_dim": 256,
+				"intermediate_size": 24576, "max_position_embeddings": 8192, "vocab_size": 256000}`,
+			want: dims{3072, 28, 16, 16, 24576, 256000, 8192, true},
+		},
+		{
+			name: "llama is untied by default",
+			config: `{"model_type": "llama", "hidden_size": 4096, "num_hidden_layers": 32,
+				"num_attention_heads": 32, "num_key_value_heads": 8, "intermediate_size": 14336,
+				"vocab_size": 128256}`,
+			want: dims{4096, 32, 32, 8, 14336, 128256, 0, false},
+		},
+		{
+			name: "Llama-style names win",
+			config: `{"model_type": "gpt2", "hidden_size": 1024, "n_embd": 768, "num_hidden_layers": 24,
+				"n_layer": 12, "n_head": 16, "vocab_size": 50257, "tie_word_embeddings": false}`,
+			want: dims{1024, 24, 16, 16, 4096, 50257, 0, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := mustParseConfig(t, tt.config)
+			got := dims{c.HiddenSize, c.NumHiddenLayers, c.NumAttentionHeads, c.NumKeyValueHeads,
+				c.IntermediateSize, c.VocabSize, c.MaxPositionEmbeddings, c.tiedEmbeddings()}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+// internal/calculator/dtypes.go
+
+package calculator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DataType represents supported model data types
+type DataType string
+
+const (
+	// Floating point
+	Float32  DataType = "float32"
+	Float16  DataType = "float16"
+	BFloat16 DataType = "bfloat16"
+	FP8E4M3  DataType = "fp8_e4m3"
+	FP8E5M2  DataType = "fp8_e5m2"
+
+	// Integer
+	Int8 DataType = "int8"
+	Int4 DataType = "int4"
+	Int3 DataType = "int3"
+	Int2 DataType = "int2"
+
+	// bitsandbytes
+	NF4 DataType = "nf4"
+	FP4 DataType = "fp4"
+
+	// Common aliases
+	FP32   DataType = "fp32"   // Alias for float32
+	F16    DataType = "f16"    // Alias for float16
+	BF16   DataType = "bf16"   // Alias for bfloat16
+	Float8 DataType = "float8" // Alias for fp8_e4m3
+	FP8    DataType = "fp8"    // Alias for fp8_e4m3
+	Q8     DataType = "q8"     // Alias for int8
+	Q4     DataType = "q4"     // Alias for int4
+)
+
+// bpwSuffix marks a data type given as fractional bits per weight, e.g. "4.5bpw"
+const bpwSuffix = "bpw"
+
+// DataTypeFamily groups related data types
+type DataTypeFamily string
+
+const (
+	FamilyFloat        DataTypeFamily = "float"
+	FamilyInteger      DataTypeFamily = "integer"
+	FamilyBitsAndBytes DataTypeFamily = "bitsandbytes"
+)
+
+// DataTypeInfo describes a data type known to the calculator
+type DataTypeInfo struct {
+	Name          DataType
+	Aliases       []DataType
+	Family        DataTypeFamily
+	BitsPerWeight float64 // Effective bits per weight, including any block scale overhead
+	KVCache       bool    // Whether serving engines support it for the KV cache
+	Description   string
+}
+
+// dataTypeRegistry is the single source of truth for supported data types.
+// Block-quantized formats include the storage of their per-block scales.
+var dataTypeRegistry = append(standardDataTypes, ggufDataTypes()...)
+
+// standardDataTypes are the floating point, integer and bitsandbytes types
+var standardDataTypes = []DataTypeInfo{
+	{
+		Name: Float32, Aliases: []DataType{FP32, "f32"}, Family: FamilyFloat,
+		BitsPerWeight: 32, Description: "32-bit floating point",
+	},
+	{
+		Name: Float16, Aliases: []DataType{F16, "fp16", "half"}, Family: FamilyFloat,
+		BitsPerWeight: 16, KVCache: true, Description: "16-bit floating point",
+	},
+	{
+		Name: BFloat16, Aliases: []DataType{BF16}, Family: FamilyFloat,
+		BitsPerWeight: 16, KVCache: true, Description: "16-bit brain floating point",
+	},
+	{
+		Name: FP8E4M3, Aliases: []DataType{FP8, Float8, "f8_e4m3"}, Family: FamilyFloat,
+		BitsPerWeight: 8, KVCache: true, Description: "8-bit floating point, 4 exponent/3 mantissa bits",
+	},
+	{
+		Name: FP8E5M2, Aliases: []DataType{"f8_e5m2"}, Family: FamilyFloat,
+		BitsPerWeight: 8, KVCache: true, Description: "8-bit floating point, 5 exponent/2 mantissa bits",
+	},
+	{
+		Name: Int8, Aliases: []DataType{Q8}, Family: FamilyInteger,
+		BitsPerWeight: 8, KVCache: true, Description: "8-bit integer quantization",
+	},
+	{
+		Name: Int4, Aliases: []DataType{Q4}, Family: FamilyInteger,
+		BitsPerWeight: 4, KVCache: true, Description: "4-bit integer quantization",
+	},
+	{
+		Name: Int3, Aliases: []DataType{"q3"}, Family: FamilyInteger,
+		BitsPerWeight: 3, Description: "3-bit integer quantization",
+	},
+	{
+		Name: Int2, Aliases: []DataType{"q2"}, Family: FamilyInteger,
+		BitsPerWeight: 2, Description: "2-bit integer quantization",
+	},
+	{
+		// 4-bit NormalFloat plus one fp32 absmax per block of 64 weights
+		Name: NF4, Aliases: []DataType{"bnb_nf4"}, Family: FamilyBitsAndBytes,
+		BitsPerWeight: 4 + 32.0/64, Description: "bitsandbytes 4-bit NormalFloat",
+	},
+	{
+		// 4-bit float plus one fp32 absmax per block of 64 weights
+		Name: FP4, Aliases: []DataType{"bnb_fp4"}, Family: FamilyBitsAndBytes,
+		BitsPerWeight: 4 + 32.0/64, Description: "bitsandbytes 4-bit float",
+	},
+}
+
+// BytesPerType maps data types and their aliases to their byte sizes
+var BytesPerType = buildBytesPerType()
+
+// KVCacheTypes lists the data types serving engines support for the KV cache
+var KVCacheTypes = filterTypes(func(info DataTypeInfo) bool { return info.KVCache })
+
+// aliasToType maps every alias to its canonical data type
+var aliasToType = buildAliases()
+
+func buildBytesPerType() map[DataType]float64 {
+	bytes := make(map[DataType]float64)
+	for _, info := range dataTypeRegistry {
+		bytes[info.Name] = info.BitsPerWeight / 8
+		for _, alias := range info.Aliases {
+			bytes[alias] = info.BitsPerWeight / 8
+		}
+	}
+	return bytes
+}
+
+func buildAliases() map[DataType]DataType {
+	aliases := make(map[DataType]DataType)
+	for _, info := range dataTypeRegistry {
+		for _, alias := range info.Aliases {
+			aliases[alias] = info.Name
+		}
+	}
+	return aliases
+}
+
+// filterTypes returns the canonical names of registry entries matching keep
+func filterTypes(keep func(DataTypeInfo) bool) []DataType {
+	types := make([]DataType, 0, len(dataTypeRegistry))
+	for _, info := range dataTypeRegistry {
+		if keep(info) {
+			types = append(types, info.Name)
+		}
+	}
+	return types
+}
+
+// NormalizeDataType converts common names to standard types
+func NormalizeDataType(dtype DataType) DataType {
+	dtype = DataType(strings.ToLower(strings.TrimSpace(string(dtype))))
+	if canonical, ok := aliasToType[dtype]; ok {
+		return canonical
+	}
+	if bits, ok := parseBitsPerWeight(dtype); ok {
+		return BitsPerWeightType(bits)
+	}
+	return dtype
+}
+
+// BitsPerWeightType returns the data type for an arbitrary bits-per-weight value
+func BitsPerWeightType(bits float64) DataType {
+	return DataType(strconv.FormatFloat(round(bits, 3), 'f', -1, 64) + bpwSuffix)
+}
+
+// parseBitsPerWeight extracts the bit width from a "<bits>bpw" data type
+func parseBitsPerWeight(dtype DataType) (float64, bool) {
+	value, found := strings.CutSuffix(string(dtype), bpwSuffix)
+	if !found {
+		return 0, false
+	}
+	bits, err := strconv.ParseFloat(value, 64)
+	if err != nil || bits <= 0 || bits > 32 {
+		return 0, false
+	}
+	return bits, true
+}
+
+// BytesPerParameter returns the storage size of one parameter for the data type
+func BytesPerParameter(dtype DataType) (float64, bool) {
+	dtype = NormalizeDataType(dtype)
+	if bytes, ok := BytesPerType[dtype]; ok {
+		return bytes, true
+	}
+	if bits, ok := parseBitsPerWeight(dtype); ok {
+		return bits / 8, true
+	}
+	return 0, false
+}
+
+// GetDataTypeInfo returns the registry entry for a data type or one of its aliases
+func GetDataTypeInfo(dtype DataType) (DataTypeInfo, bool) {
+	dtype = NormalizeDataType(dtype)
+	for _, info := range dataTypeRegistry {
+		if info.Name == dtype {
+			return info, true
+		}
+	}
+	return DataTypeInfo{}, false
+}
+
+// ValidateDataType checks if the provided data type is supported
+func ValidateDataType(dtype DataType) bool {
+	_, ok := BytesPerParameter(dtype)
+	return ok
+}
+
+// ValidateKVDataType checks if the provided data type can be used for the KV cache
+func ValidateKVDataType(dtype DataType) bool {
+	info, ok := GetDataTypeInfo(dtype)
+	return ok && info.KVCache
+}
+
+// GetSupportedTypes returns a list of supported data types
+func GetSupportedTypes() []DataType {
+	return filterTypes(func(DataTypeInfo) bool { return true })
+}
+
+// GetDataTypeFamilies returns the data type families in registry order
+func GetDataTypeFamilies() []DataTypeFamily {
+	var families []DataTypeFamily
+	seen := make(map[DataTypeFamily]bool)
+	for _, info := range dataTypeRegistry {
+		if !seen[info.Family] {
+			seen[info.Family] = true
+			families = append(families, info.Family)
+		}
+	}
+	return families
+}
+
+// GetTypesByFamily returns the data types belonging to a family
+func GetTypesByFamily(family DataTypeFamily) []DataType {
+	return filterTypes(func(info DataTypeInfo) bool { return info.Family == family })
+}
+
+// DescribeSupportedTypes returns a human readable list of supported data types
+func DescribeSupportedTypes() string {
+	return describeTypes(GetSupportedTypes()) +
+		", fractional <bits>bpw (e.g. 4.5bpw), or native (checkpoint dtypes)"
+}
+
+// DescribeKVCacheTypes returns a human readable list of KV cache data types
+func DescribeKVCacheTypes() string {
+	return describeTypes(KVCacheTypes)
+}
+
+// describeTypes formats data types with their aliases, e.g. "float16/f16/fp16"
+func describeTypes(types []DataType) string {
+	parts := make([]string, 0, len(types))
+	for _, dtype := range types {
+		info, _ := GetDataTypeInfo(dtype)
+		names := []string{string(info.Name)}
+		for _, alias := range info.Aliases {
+			names = append(names, string(alias))
+		}
+		parts = append(parts, strings.Join(names, "/"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ErrUnsupportedDataType represents an error for unsupported data types
+type ErrUnsupportedDataType struct {
+	DataType DataType
+}
+
+func (e ErrUnsupportedDataType) Error() string {
+	return fmt.Sprintf("unsupported data type: %s", e.DataType)
+}
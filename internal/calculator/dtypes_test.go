@@ -0,0 +1,68 @@
+// internal/calculator/dtypes_test.go
+
+package calculator
+
+import "testing"
+
+func TestBytesPerParameter(t *testing.T) {
+	tests := []struct {
+		dtype DataType
+		want  float64 // 0 when unsupported
+	}{
+		{"float32", 4},
+		{"fp32", 4},
+		{"float16", 2},
+		{"half", 2},
+		{"BF16", 2},
+		{"fp8", 1},
+		{"float8", 1},
+		{"f8_e5m2", 1},
+		{"int8", 1},
+		{"q4", 0.5},
+		{"int3", 0.375},
+		{"int2", 0.25},
+		{"nf4", 0.5625}, // 4 bits plus an fp32 absmax per 64 weights
+		{"bnb_fp4", 0.5625},
+		{"4.5bpw", 0.5625},
+		{" 2.25BPW ", 0.28125},
+		{"33bpw", 0},
+		{"0bpw", 0},
+		{"fp12", 0},
+	}
+	for _, tt := range tests {
+		got, ok := BytesPerParameter(tt.dtype)
+		if ok != (tt.want > 0) || got != tt.want {
+			t.Errorf("BytesPerParameter(%q) = %g, %v, want %g", tt.dtype, got, ok, tt.want)
+		}
+	}
+}
+
+func TestNormalizeDataType(t *testing.T) {
+	tests := []struct {
+		dtype, want DataType
+	}{
+		{"FP8", FP8E4M3},
+		{"fp16", Float16},
+		{"Q8", Int8},
+		{"bnb_nf4", NF4},
+		{"4.50bpw", "4.5bpw"},
+		{"6.5625bpw", "6.563bpw"},
+		{"unknown", "unknown"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeDataType(tt.dtype); got != tt.want {
+			t.Errorf("NormalizeDataType(%q) = %q, want %q", tt.dtype, got, tt.want)
+		}
+	}
+}
+
+func TestValidateKVDataType(t *testing.T) {
+	for dtype, want := range map[DataType]bool{
+		"bf16": true, "fp8": true, "fp8_e5m2": true, "int4": true,
+		"float32": false, "nf4": false, "int3": false, "4.5bpw": false,
+	} {
+		if got := ValidateKVDataType(dtype); got != want {
+			t.Errorf("ValidateKVDataType(%q) = %v, want %v", dtype, got, want)
+		}
+	}
+}
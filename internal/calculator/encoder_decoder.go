@@ -0,0 +1,88 @@
+// internal/calculator/encoder_decoder.go
+
+package calculator
+
+import "fmt"
+
+// encoderDecoderFamilies lists encoder-decoder families for configs that omit
+// is_encoder_decoder
+var encoderDecoderFamilies = map[string]bool{
+	"t5":       true,
+	"mt5":      true,
+	"umt5":     true,
+	"longt5":   true,
+	"bart":     true,
+	"mbart":    true,
+	"pegasus":  true,
+	"marian":   true,
+	"whisper":  true,
+	"nllb-moe": true,
+}
+
+// resolveEncoderDecoder maps the T5 (d_model, num_layers, num_decoder_layers)
+// and BART/Whisper (encoder_layers, decoder_layers) field names onto the
+// decoder dimensions the KV cache is sized from. BART and Whisper also save
+// num_hidden_layers, which counts the encoder layers, so decoder-specific
+// fields take precedence.
+func (c *ModelConfig) resolveEncoderDecoder() {
+	if !c.IsEncoderDecoder && !encoderDecoderFamilies[c.family()] {
+		return
+	}
+	c.IsEncoderDecoder = true
+
+	if c.HiddenSize == 0 {
+		c.HiddenSize = c.DModel
+	}
+	if c.HeadDim == 0 {
+		c.HeadDim = c.DKV
+	}
+	c.EncoderLayers = firstPositive(c.EncoderLayers, c.NumLayers, c.NumHiddenLayers)
+	c.NumHiddenLayers = firstPositive(c.NumDecoderLayers, c.DecoderLayers, c.NumLayers, c.NumHiddenLayers)
+	c.NumAttentionHeads = firstPositive(c.DecoderAttentionHeads, c.NumHeads, c.NumAttentionHeads)
+	c.IntermediateSize = firstPositive(c.DecoderFFNDim, c.DFF, c.IntermediateSize)
+	c.MaxPositionEmbeddings = firstPositive(c.MaxTargetPositions, c.MaxPositionEmbeddings)
+}
+
+// firstPositive returns the first positive value, or 0
+func firstPositive(values ...int) int {
+	for _, v := range values {
+		if v > 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+// EncoderDecoderLengths returns the encoder input and decoder output tokens each
+// user caches. The encoder input defaults to the context length, or to
+// max_source_positions for Whisper, which pads every input to 30 seconds of
+// audio; the decoder output defaults to the context length.
+func (p KVCacheParams) EncoderDecoderLengths() (encoder, decoder int) {
+	encoder = p.EncoderInputLength
+	if encoder == 0 {
+		encoder = p.ContextLength
+		if p.Config != nil && p.Config.MaxSourcePositions > 0 {
+			encoder = p.Config.MaxSourcePositions
+		}
+	}
+	decoder = p.DecoderOutputLength
+	if decoder == 0 {
+		decoder = p.ContextLength
+	}
+	return encoder, decoder
+}
+
+// crossAttentionElements returns the cached encoder keys and values of decoder
+// layers [firstLayer, lastLayer) for one sequence. Every decoder layer of an
+// encoder-decoder model cross-attends to the whole encoder output.
+func (c *ModelConfig) crossAttentionElements(seq sequenceTokens, firstLayer, lastLayer, kvHeads int) float64 {
+	if !c.IsEncoderDecoder {
+		return 0
+	}
+	return float64((lastLayer-firstLayer)*seq.cross) * c.kvElementsPerToken(attentionFull, kvHeads)
+}
+
+// encoderDecoderSummary describes the encoder and the decoder's cross-attention
+func (c *ModelConfig) encoderDecoderSummary() string {
+	return fmt.Sprintf("cross-attention to the encoder output in every decoder layer, %d encoder layers", c.EncoderLayers)
+}
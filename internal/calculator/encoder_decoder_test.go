@@ -0,0 +1,128 @@
+// internal/calculator/encoder_decoder_test.go
+
+package calculator
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// parseEncoderDecoderConfig decodes a config.json and resolves its encoder and
+// decoder field names as FetchModelConfig does
+func parseEncoderDecoderConfig(t *testing.T, data string) *ModelConfig {
+	t.Helper()
+	var config ModelConfig
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	config.resolveEncoderDecoder()
+	if config.NumKeyValueHeads == 0 {
+		config.NumKeyValueHeads = config.NumAttentionHeads
+	}
+	return &config
+}
+
+// google-t5/t5-base
+const t5Config = `{
+	"model_type": "t5", "d_model": 768, "d_kv": 64, "d_ff": 3072,
+	"num_heads": 12, "num_layers": 12, "vocab_size": 32128, "is_encoder_decoder": true
+}`
+
+// openai/whisper-large-v3
+const whisperConfig = `{
+	"model_type": "whisper", "d_model": 1280, "encoder_layers": 32, "decoder_layers": 32,
+	"encoder_attention_heads": 20, "decoder_attention_heads": 20, "decoder_ffn_dim": 5120,
+	"num_hidden_layers": 32, "max_source_positions": 1500, "max_target_positions": 448,
+	"vocab_size": 51866
+}`
+
+func TestResolveEncoderDecoder(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    [5]int // hidden, decoder layers, encoder layers, heads, head_dim
+		maxPos  int
+		encoder bool
+	}{
+		{"t5-base", t5Config, [5]int{768, 12, 12, 12, 64}, 0, true},
+		{
+			// google/flan-t5-xl
+			name: "flan-t5-xl",
+			config: `{"model_type": "t5", "d_model": 2048, "d_kv": 64, "d_ff": 5120, "num_heads": 32,
+				"num_layers": 24, "num_decoder_layers": 24}`,
+			want:    [5]int{2048, 24, 24, 32, 64},
+			encoder: true,
+		},
+		{"whisper-large-v3", whisperConfig, [5]int{1280, 32, 32, 20, 64}, 448, true},
+		{
+			// facebook/bart-large
+			name: "bart-large",
+			config: `{"model_type": "bart", "d_model": 1024, "encoder_layers": 12, "decoder_layers": 12,
+				"decoder_attention_heads": 16, "num_hidden_layers": 12, "max_position_embeddings": 1024}`,
+			want:    [5]int{1024, 12, 12, 16, 64},
+			maxPos:  1024,
+			encoder: true,
+		},
+		{"decoder-only", `{"model_type": "llama", "hidden_size": 4096, "num_hidden_layers": 32,
+			"num_attention_heads": 32}`, [5]int{4096, 32, 0, 32, 128}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := parseEncoderDecoderConfig(t, tt.config)
+			got := [5]int{c.HiddenSize, c.NumHiddenLayers, c.EncoderLayers, c.NumAttentionHeads, c.headDim()}
+			if got != tt.want || c.MaxPositionEmbeddings != tt.maxPos || c.IsEncoderDecoder != tt.encoder {
+				t.Errorf("resolved %v, %d positions, encoder-decoder %v, want %v, %d, %v",
+					got, c.MaxPositionEmbeddings, c.IsEncoderDecoder, tt.want, tt.maxPos, tt.encoder)
+			}
+		})
+	}
+}
+
+func TestCalculateKVCacheEncoderDecoder(t *testing.T) {
+	const bf16 = 2.0
+
+	tests := []struct {
+		name   string
+		config string
+		params KVCacheParams
+		want   float64 // Bytes for all users
+	}{
+		{
+			// Self-attention over the decoder output, cross-attention over the encoder input
+			name:   "t5 with separate lengths",
+			config: t5Config,
+			params: KVCacheParams{Users: 8, ContextLength: 512, EncoderInputLength: 512, DecoderOutputLength: 128},
+			want:   8 * 12 * (128 + 512) * 2 * 12 * 64 * bf16,
+		},
+		{
+			name:   "t5 defaults both lengths to the context",
+			config: t5Config,
+			params: KVCacheParams{Users: 8, ContextLength: 512},
+			want:   8 * 12 * (512 + 512) * 2 * 12 * 64 * bf16,
+		},
+		{
+			// Whisper pads every input to 1500 encoder positions
+			name:   "whisper caches the padded audio",
+			config: whisperConfig,
+			params: KVCacheParams{Users: 16, ContextLength: 448},
+			want:   16 * 32 * (448 + 1500) * 2 * 20 * 64 * bf16,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			params.Config = parseEncoderDecoderConfig(t, tt.config)
+			params.KVDataType = BFloat16
+
+			got, err := CalculateKVCache(params)
+			if err != nil {
+				t.Fatalf("CalculateKVCache: %v", err)
+			}
+			if want := round(tt.want/bytesPerGiB, 2); got != want {
+				t.Errorf("CalculateKVCache() = %.2f GB, want %.2f GB", got, want)
+			}
+		})
+	}
+}
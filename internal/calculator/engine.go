@@ -0,0 +1,392 @@
+// internal/calculator/engine.go
+
+package calculator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Engine identifies a serving engine
+type Engine string
+
+// Supported serving engines
+const (
+	EngineVLLM        Engine = "vllm"
+	EngineTGI         Engine = "tgi"
+	EngineSGLang      Engine = "sglang"
+	EngineTensorRTLLM Engine = "tensorrt-llm"
+	EngineLlamaCpp    Engine = "llama.cpp"
+	EngineOllama      Engine = "ollama"
+)
+
+// EngineParams holds the inputs for estimating a serving engine's memory
+type EngineParams struct {
+	ParametersB float64 // Total parameters in billions
+	WeightsGB   float64 // Single-GPU weight memory from CalculateWeightMemory
+	KV          KVCacheParams
+	Parallel    ParallelConfig
+	Overhead    OverheadModel
+	GPUMemoryGB float64     // Memory of each GPU, 0 when no GPU is selected
+	Draft       DraftMemory // Speculative decoding, its KV cache comes out of the engine's KV cache memory
+}
+
+// EngineDetail is a figure reported in the engine's own terminology
+type EngineDetail struct {
+	Name  string
+	Value string
+}
+
+// EngineEstimate is the memory a serving engine allocates for a workload
+type EngineEstimate struct {
+	Engine         Engine
+	PerGPU         []MemoryBreakdown // One breakdown per pipeline stage
+	Users          int
+	MaxSequences   int // Sequences the engine runs at once, 0 when only the KV cache limits it
+	KVTokens       int // Tokens the KV cache holds
+	RequiredTokens int // Tokens the requested users and context need
+	Details        []EngineDetail
+}
+
+// ServesWorkload reports whether the engine runs the requested users at once and
+// its KV cache holds their context
+func (e EngineEstimate) ServesWorkload() bool {
+	if e.MaxSequences > 0 && e.Users > e.MaxSequences {
+		return false
+	}
+	return e.KVTokens >= e.RequiredTokens
+}
+
+// EngineProfile models how a serving engine allocates GPU memory
+type EngineProfile interface {
+	Name() Engine
+	Description() string
+	Estimate(params EngineParams) (EngineEstimate, error)
+}
+
+// engineProfiles lists the supported engines in display order
+var engineProfiles = []EngineProfile{
+	NewVLLMEngine(DefaultPagedKVCacheParams()),
+	pagedEngine{
+		name:        EngineTGI,
+		description: "warms up with max_batch_prefill_tokens, KV cache from the remaining memory",
+		settings: PagedKVCacheParams{
+			BlockSize:            16,
+			GPUMemoryUtilization: 1.0,
+			MaxNumSeqs:           128,
+			MaxNumBatchedTokens:  4096,
+		},
+		ofFreeMemory: true,
+		fractionName: "cuda_memory_fraction",
+		seqsName:     "max_concurrent_requests",
+		prefillName:  "max_batch_prefill_tokens",
+		capacityName: "max_batch_total_tokens",
+	},
+	pagedEngine{
+		name:        EngineSGLang,
+		description: "pre-allocates mem_fraction_static of the GPU as a token pool",
+		settings: PagedKVCacheParams{
+			BlockSize:            1,
+			GPUMemoryUtilization: 0.88,
+			MaxNumBatchedTokens:  8192,
+		},
+		fractionName: "mem_fraction_static",
+		prefillName:  "chunked_prefill_size",
+		capacityName: "max_total_num_tokens",
+		reserveGB:    0.5, // CUDA graph memory pool
+	},
+	pagedEngine{
+		name:        EngineTensorRTLLM,
+		description: "paged KV cache from kv_cache_free_gpu_mem_fraction of the free memory",
+		settings: PagedKVCacheParams{
+			BlockSize:            64,
+			GPUMemoryUtilization: 0.9,
+			MaxNumSeqs:           2048,
+			MaxNumBatchedTokens:  8192,
+		},
+		ofFreeMemory: true,
+		fractionName: "kv_cache_free_gpu_mem_fraction",
+		blockName:    "tokens_per_block",
+		seqsName:     "max_batch_size",
+		prefillName:  "max_num_tokens",
+		capacityName: "max_tokens_in_paged_kv_cache",
+	},
+	llamaCppEngine{
+		name:         EngineLlamaCpp,
+		description:  "KV cache for n_ctx shared by n_parallel slots, compute buffer per n_ubatch",
+		contextName:  "n_ctx",
+		parallelName: "n_parallel",
+	},
+	llamaCppEngine{
+		name:         EngineOllama,
+		description:  "llama.cpp with num_ctx per request and OLLAMA_NUM_PARALLEL slots",
+		contextName:  "num_ctx",
+		parallelName: "OLLAMA_NUM_PARALLEL",
+		reserveGB:    0.45, // Minimum free memory Ollama keeps per GPU
+	},
+}
+
+// NewVLLMEngine returns the vLLM profile with the given PagedAttention settings
+func NewVLLMEngine(settings PagedKVCacheParams) EngineProfile {
+	return pagedEngine{
+		name:          EngineVLLM,
+		description:   "pre-allocates gpu_memory_utilization of the GPU, KV cache in blocks",
+		settings:      settings,
+		fractionName:  "gpu_memory_utilization",
+		blockName:     "block_size",
+		seqsName:      "max_num_seqs",
+		prefillName:   "max_num_batched_tokens",
+		capacityName:  "# GPU blocks",
+		inBlocks:      true,
+		samplesLogits: true,
+		reserveGB:     0.5, // CUDA graph memory pool
+	}
+}
+
+// engineAliases maps alternative engine names to engines
+var engineAliases = map[string]Engine{
+	"llamacpp":  EngineLlamaCpp,
+	"llama-cpp": EngineLlamaCpp,
+	"trtllm":    EngineTensorRTLLM,
+	"trt-llm":   EngineTensorRTLLM,
+	"tensorrt":  EngineTensorRTLLM,
+}
+
+// GetEngines returns the supported engine profiles
+func GetEngines() []EngineProfile {
+	return engineProfiles
+}
+
+// LookupEngine finds an engine profile by name or alias, ignoring case
+func LookupEngine(name string) (EngineProfile, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if engine, ok := engineAliases[name]; ok {
+		name = string(engine)
+	}
+	for _, profile := range engineProfiles {
+		if string(profile.Name()) == name {
+			return profile, true
+		}
+	}
+	return nil, false
+}
+
+// DescribeEngines lists the supported engine names
+func DescribeEngines() string {
+	names := make([]string, len(engineProfiles))
+	for i, profile := range engineProfiles {
+		names[i] = string(profile.Name())
+	}
+	return strings.Join(names, ", ")
+}
+
+// pagedEngine models engines that allocate the KV cache up front in blocks from
+// the memory left after the weights and a profiling forward pass
+type pagedEngine struct {
+	name          Engine
+	description   string
+	settings      PagedKVCacheParams
+	ofFreeMemory  bool // The fraction applies to the memory left after weights and reservations
+	fractionName  string
+	blockName     string // Empty when the block size isn't configurable
+	seqsName      string // Empty when concurrency is only limited by the KV cache
+	prefillName   string
+	capacityName  string
+	inBlocks      bool    // The capacity is reported in blocks rather than tokens
+	samplesLogits bool    // The profiling run samples logits for every sequence
+	reserveGB     float64 // Runtime memory the engine reserves per GPU
+}
+
+// Name returns the engine name
+func (e pagedEngine) Name() Engine { return e.name }
+
+// Description summarizes how the engine allocates memory
+func (e pagedEngine) Description() string { return e.description }
+
+// Estimate sizes the KV cache the engine allocates. Without a GPU the cache is
+// sized for the workload, rounded up to whole blocks.
+func (e pagedEngine) Estimate(params EngineParams) (EngineEstimate, error) {
+	settings := e.settings
+	if err := settings.Validate(); err != nil {
+		return EngineEstimate{}, err
+	}
+
+	config := params.KV.Config
+	overhead := params.Overhead
+	overhead.EngineReserveGB += e.reserveGB
+
+	// The profiling run prefills max_num_batched_tokens and keeps only the last
+	// token's logits
+	activations := engineActivationsGB(ActivationParams{
+		ContextLength:   settings.MaxNumBatchedTokens,
+		LastTokenLogits: true,
+		Config:          config,
+	}, params.Parallel)
+	if e.samplesLogits {
+		activations += samplerLogitsGB(config, settings.MaxNumSeqs)
+	}
+
+	kv := params.KV
+	sequenceBlocks := settings.blocksPerSequence(kv.ContextLength)
+	kv.ContextLength = sequenceBlocks * settings.BlockSize
+	perGPU, err := engineBreakdowns(params, kv, activations, overhead)
+	if err != nil {
+		return EngineEstimate{}, err
+	}
+
+	// State-space models without attention layers keep no tokens in blocks
+	paged := config == nil || config.NumHiddenLayers == 0 || config.hasAttentionLayers()
+	requestBlocks := settings.blocksPerRequest(params.KV)
+	requiredTokens := kv.Users * requestBlocks * settings.BlockSize
+	cache := newPagedKVCache(kv.Users*requestBlocks, settings.BlockSize, params.KV.pagedTokens())
+	if !paged {
+		requiredTokens = 0
+		cache = newPagedKVCache(0, settings.BlockSize, params.KV.pagedTokens())
+		cache.MaxConcurrency = float64(kv.Users)
+	}
+	if params.GPUMemoryGB > 0 {
+		// The pool is whatever the engine's share leaves on each GPU, and the
+		// draft's cache on the last stage leaves less of it to the target's blocks
+		_, draftKV := ParallelParams{Parallel: params.Parallel, Draft: params.Draft}.draftShare(0, 0)
+		available := make([]float64, len(perGPU))
+		for i, b := range perGPU {
+			used := b.WeightsGB + b.OverheadGB + b.ActivationsGB
+			available[i] = settings.GPUMemoryUtilization*params.GPUMemoryGB - used
+			if e.ofFreeMemory {
+				available[i] = settings.GPUMemoryUtilization * (params.GPUMemoryGB - used)
+			}
+			perGPU[i].KVCacheGB = round(max(available[i], 0), 2)
+			if i == len(perGPU)-1 {
+				available[i] -= draftKV
+			}
+		}
+		cache, err = CalculatePagedKVCache(params.ParametersB, params.KV, params.Parallel, settings, available)
+		if err != nil {
+			return EngineEstimate{}, err
+		}
+	}
+
+	estimate := EngineEstimate{
+		Engine:         e.name,
+		PerGPU:         perGPU,
+		Users:          kv.Users,
+		MaxSequences:   settings.MaxNumSeqs,
+		KVTokens:       cache.CapacityTokens,
+		RequiredTokens: requiredTokens,
+	}
+
+	estimate.Details = append(estimate.Details,
+		EngineDetail{e.fractionName, fmt.Sprintf("%.2f", settings.GPUMemoryUtilization)})
+	if e.blockName != "" {
+		estimate.Details = append(estimate.Details, EngineDetail{e.blockName, fmt.Sprint(settings.BlockSize)})
+	}
+	if e.seqsName != "" {
+		estimate.Details = append(estimate.Details, EngineDetail{e.seqsName, fmt.Sprint(settings.MaxNumSeqs)})
+	}
+	estimate.Details = append(estimate.Details, EngineDetail{e.prefillName, fmt.Sprint(settings.MaxNumBatchedTokens)})
+	if e.inBlocks {
+		estimate.Details = append(estimate.Details,
+			EngineDetail{e.capacityName, fmt.Sprint(cache.NumBlocks)},
+			EngineDetail{"GPU KV cache size", fmt.Sprintf("%d tokens", cache.CapacityTokens)})
+	} else {
+		estimate.Details = append(estimate.Details, EngineDetail{e.capacityName, fmt.Sprint(cache.CapacityTokens)})
+	}
+	if params.Draft.KVCacheGB > 0 {
+		estimate.Details = append(estimate.Details, EngineDetail{"Draft KV cache", fmt.Sprintf("%.2f GB", params.Draft.KVCacheGB)})
+	}
+	if cache.StateGB > 0 {
+		estimate.Details = append(estimate.Details, EngineDetail{"Recurrent state", fmt.Sprintf("%.2f GB", cache.StateGB)})
+	}
+	estimate.Details = append(estimate.Details, EngineDetail{
+		fmt.Sprintf("Maximum concurrency for %d tokens per request", params.KV.pagedTokens()),
+		fmt.Sprintf("%.2fx", cache.MaxConcurrency),
+	})
+
+	return estimate, nil
+}
+
+// llamaCppEngine models llama.cpp based engines, which allocate the KV cache for
+// the whole context and a compute buffer sized by the context and micro-batch
+type llamaCppEngine struct {
+	name         Engine
+	description  string
+	contextName  string
+	parallelName string
+	reserveGB    float64 // Memory the engine keeps free per GPU
+}
+
+// llamaCppUBatch is the default physical batch size (n_ubatch)
+const llamaCppUBatch = 512
+
+// Name returns the engine name
+func (e llamaCppEngine) Name() Engine { return e.name }
+
+// Description summarizes how the engine allocates memory
+func (e llamaCppEngine) Description() string { return e.description }
+
+// Estimate sizes the KV cache for the context of every slot and adds the
+// compute buffer. The compute buffer holds the attention scores of a micro-batch
+// over the whole context and the fp32 logits of the micro-batch.
+func (e llamaCppEngine) Estimate(params EngineParams) (EngineEstimate, error) {
+	totalContext := params.KV.Users * params.KV.ContextLength
+
+	overhead := params.Overhead
+	overhead.EngineReserveGB += e.reserveGB
+
+	var computeBuffer float64
+	if config := params.KV.Config; config != nil {
+		scores := float64(llamaCppUBatch) * float64(totalContext) * float64(config.NumAttentionHeads)
+		logits := float64(llamaCppUBatch) * float64(config.VocabSize)
+		computeBuffer = (scores + logits) * bytesPerFloat32 / bytesPerGiB
+	}
+
+	perGPU, err := engineBreakdowns(params, params.KV, computeBuffer, overhead)
+	if err != nil {
+		return EngineEstimate{}, err
+	}
+
+	return EngineEstimate{
+		Engine:         e.name,
+		PerGPU:         perGPU,
+		Users:          params.KV.Users,
+		KVTokens:       totalContext,
+		RequiredTokens: totalContext,
+		Details: []EngineDetail{
+			{e.contextName, fmt.Sprint(params.KV.ContextLength)},
+			{e.parallelName, fmt.Sprint(params.KV.Users)},
+			{"n_ubatch", fmt.Sprint(llamaCppUBatch)},
+		},
+	}, nil
+}
+
+// engineBreakdowns splits the weights and the KV cache for kv over the GPUs,
+// each holding the engine's activations
+func engineBreakdowns(params EngineParams, kv KVCacheParams, activationsGB float64, overhead OverheadModel) ([]MemoryBreakdown, error) {
+	kvMemory, err := kvCacheGB(params.ParametersB, kv)
+	if err != nil {
+		return nil, err
+	}
+	return gpuBreakdowns(ParallelParams{
+		ParametersB:   params.ParametersB,
+		WeightsGB:     params.WeightsGB,
+		ActivationsGB: activationsGB,
+		KVCacheGB:     kvMemory,
+		KV:            kv,
+		Parallel:      params.Parallel,
+		Overhead:      overhead,
+		Draft:         params.Draft,
+	})
+}
+
+// engineActivationsGB returns the activations of the engine's forward pass, or
+// 0 without a config
+func engineActivationsGB(params ActivationParams, parallel ParallelConfig) float64 {
+	if params.Config == nil {
+		return 0
+	}
+	activations, err := CalculateActivationMemory(params, parallel.TensorParallel)
+	if err != nil {
+		return 0
+	}
+	return activations
+}
@@ -0,0 +1,193 @@
+// internal/calculator/engine_test.go
+
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+// llama3EngineParams serves Meta-Llama-3-8B in bfloat16 on a single GPU
+func llama3EngineParams() EngineParams {
+	return EngineParams{
+		ParametersB: 8.03,
+		WeightsGB:   16.06,
+		KV:          KVCacheParams{Users: 4, ContextLength: 8192, DataType: BFloat16, Config: llama3Config()},
+		Parallel:    ParallelConfig{TensorParallel: 1, PipelineParallel: 1},
+		Overhead:    DefaultOverhead(),
+	}
+}
+
+func TestLookupEngine(t *testing.T) {
+	tests := []struct {
+		name string
+		want Engine
+	}{
+		{"vllm", EngineVLLM},
+		{" VLLM ", EngineVLLM},
+		{"tgi", EngineTGI},
+		{"sglang", EngineSGLang},
+		{"trtllm", EngineTensorRTLLM},
+		{"TensorRT-LLM", EngineTensorRTLLM},
+		{"llamacpp", EngineLlamaCpp},
+		{"ollama", EngineOllama},
+	}
+
+	for _, tt := range tests {
+		profile, ok := LookupEngine(tt.name)
+		if !ok || profile.Name() != tt.want {
+			t.Errorf("LookupEngine(%q) = %v, %v, want %s", tt.name, profile, ok, tt.want)
+		}
+	}
+	if _, ok := LookupEngine("triton"); ok {
+		t.Error("LookupEngine(triton) found an engine")
+	}
+}
+
+func TestPagedEngineCapacity(t *testing.T) {
+	const perTokenGiB = 128.0 / (1024 * 1024) // Llama-3-8B caches 128 KiB per token
+
+	tests := []struct {
+		engine    string
+		gpuGB     float64
+		blockSize int
+		pool      func(weights, overhead float64) float64
+	}{
+		{"vllm", 80, 16, func(w, o float64) float64 { return 0.9*80 - w - o }},
+		{"sglang", 80, 1, func(w, o float64) float64 { return 0.88*80 - w - o }},
+		{"tgi", 40, 16, func(w, o float64) float64 { return 40 - w - o }},
+		{"tensorrt-llm", 40, 64, func(w, o float64) float64 { return 0.9 * (40 - w - o) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.engine, func(t *testing.T) {
+			engine, _ := LookupEngine(tt.engine)
+			params := llama3EngineParams()
+			params.GPUMemoryGB = tt.gpuGB
+			estimate, err := engine.Estimate(params)
+			if err != nil {
+				t.Fatalf("Estimate() error = %v", err)
+			}
+
+			b := estimate.PerGPU[0]
+			pool := tt.pool(b.WeightsGB, b.OverheadGB+b.ActivationsGB)
+			if math.Abs(b.KVCacheGB-pool) > 0.01 {
+				t.Errorf("KVCacheGB = %.2f, want the %.2f GB pool", b.KVCacheGB, pool)
+			}
+			if want := pool / perTokenGiB; math.Abs(float64(estimate.KVTokens)-want)/want > 0.01 {
+				t.Errorf("KVTokens = %d, want about %.0f", estimate.KVTokens, want)
+			}
+			if estimate.KVTokens%tt.blockSize != 0 {
+				t.Errorf("KVTokens = %d, want whole blocks of %d", estimate.KVTokens, tt.blockSize)
+			}
+			if !estimate.ServesWorkload() {
+				t.Errorf("%d tokens do not serve the %d required", estimate.KVTokens, estimate.RequiredTokens)
+			}
+		})
+	}
+}
+
+func TestPagedEngineWithoutGPU(t *testing.T) {
+	engine, _ := LookupEngine("vllm")
+	params := llama3EngineParams()
+	params.KV.ContextLength = 1000
+
+	estimate, err := engine.Estimate(params)
+	if err != nil {
+		t.Fatalf("Estimate() error = %v", err)
+	}
+	// The context is rounded up to whole blocks of 16 tokens
+	if want := 4 * 1008; estimate.RequiredTokens != want || estimate.KVTokens != want {
+		t.Errorf("tokens = %d of %d, want %d", estimate.KVTokens, estimate.RequiredTokens, want)
+	}
+}
+
+func TestLlamaCppEngineComputeBuffer(t *testing.T) {
+	params := llama3EngineParams()
+	params.KV.Users = 1
+
+	// n_ubatch attention scores over the context and fp32 logits over the vocabulary
+	scores := 512.0 * 8192 * 32 * 4
+	logits := 512.0 * 128256 * 4
+	computeGB := round((scores+logits)/bytesPerGiB, 2)
+
+	tests := []struct {
+		engine    string
+		reserveGB float64
+	}{
+		{"llama.cpp", 0},
+		{"ollama", 0.45},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.engine, func(t *testing.T) {
+			engine, _ := LookupEngine(tt.engine)
+			estimate, err := engine.Estimate(params)
+			if err != nil {
+				t.Fatalf("Estimate() error = %v", err)
+			}
+			overhead := params.Overhead
+			overhead.EngineReserveGB = tt.reserveGB
+			b := estimate.PerGPU[0]
+			if want := overhead.PerGPU(params.WeightsGB); b.OverheadGB != want {
+				t.Errorf("OverheadGB = %.2f, want %.2f", b.OverheadGB, want)
+			}
+			if b.ActivationsGB != computeGB {
+				t.Errorf("ActivationsGB = %.2f, want the %.2f GB compute buffer", b.ActivationsGB, computeGB)
+			}
+			if b.KVCacheGB != 1 {
+				t.Errorf("KVCacheGB = %.2f, want 1 GiB for 8192 tokens", b.KVCacheGB)
+			}
+			if estimate.KVTokens != 8192 {
+				t.Errorf("KVTokens = %d, want 8192", estimate.KVTokens)
+			}
+		})
+	}
+}
+
+// A draft model's KV cache is counted by every engine and shrinks the pool of paged engines
+func TestEngineDraftKVCache(t *testing.T) {
+	params := llama3EngineParams()
+	draft := DraftMemory{Method: SpeculativeDraft, WeightsGB: 2, KVCacheGB: 1.5}
+	// The proportional overhead grows with the draft weights
+	draftGB := draft.WeightsGB*(1+params.Overhead.Proportional) + draft.KVCacheGB
+
+	for _, name := range []string{"llama.cpp", "vllm"} {
+		engine, ok := LookupEngine(name)
+		if !ok {
+			t.Fatalf("LookupEngine(%q) not found", name)
+		}
+		without, err := engine.Estimate(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		withDraft := params
+		withDraft.Draft = draft
+		with, err := engine.Estimate(withDraft)
+		if err != nil {
+			t.Fatal(err)
+		}
+		added := PeakBreakdown(with.PerGPU).Total() - PeakBreakdown(without.PerGPU).Total()
+		if math.Abs(added-draftGB) > 0.01 {
+			t.Errorf("%s: draft adds %.2f GB, want %.2f GB", name, added, draftGB)
+		}
+	}
+
+	// On a GPU the draft's cache comes out of the target's blocks
+	engine := NewVLLMEngine(DefaultPagedKVCacheParams())
+	params.GPUMemoryGB = 40
+	without, err := engine.Estimate(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params.Draft = draft
+	with, err := engine.Estimate(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	perToken := 2 * 32 * 8 * 128 * 2.0 / bytesPerGiB
+	lost := float64(without.KVTokens-with.KVTokens) * perToken
+	if math.Abs(lost-draftGB) > 0.01 {
+		t.Errorf("draft takes %.2f GB of blocks, want %.2f GB", lost, draftGB)
+	}
+}
@@ -0,0 +1,203 @@
+// internal/calculator/gguf.go
+
+package calculator
+
+// GGUF data type families
+const (
+	FamilyGGUF   DataTypeFamily = "gguf"
+	FamilyGGUFIQ DataTypeFamily = "gguf-iq"
+)
+
+// ggufBlockBits holds the storage cost of each ggml block type in bits per weight,
+// including the per-block and per-super-block scales stored alongside the weights
+var ggufBlockBits = map[string]float64{
+	"f16":     16,
+	"q8_0":    8.5,    // 32 weights + fp16 scale
+	"q6_k":    6.5625, // 256 weights, 8-bit sub-block scales + fp16 super-block scale
+	"q5_1":    6.0,    // 32 weights + fp16 scale and min
+	"q5_0":    5.5,    // 32 weights + fp16 scale
+	"q5_k":    5.5,    // 256 weights, 6-bit sub-block scales/mins + fp16 scale/min
+	"q4_1":    5.0,    // 32 weights + fp16 scale and min
+	"q4_0":    4.5,    // 32 weights + fp16 scale
+	"q4_k":    4.5,    // 256 weights, 6-bit sub-block scales/mins + fp16 scale/min
+	"q3_k":    3.4375, // 256 weights, 6-bit sub-block scales + fp16 scale
+	"q2_k":    2.625,  // 256 weights, 4-bit sub-block scales/mins + fp16 scale/min
+	"iq4_nl":  4.5,
+	"iq4_xs":  4.25,
+	"iq3_s":   3.4375,
+	"iq3_xxs": 3.0625,
+	"iq2_s":   2.5,
+	"iq2_xs":  2.3125,
+	"iq2_xxs": 2.0625,
+	"iq1_m":   1.75,
+	"iq1_s":   1.5625,
+}
+
+// ggufTensorClass identifies tensors that llama.cpp quantization mixes upgrade
+type ggufTensorClass int
+
+const (
+	ggufAttnV ggufTensorClass = iota
+	ggufAttnOutput
+	ggufFFNDown
+	ggufOutput
+)
+
+// ggufReferenceShares is the fraction of parameters held by each tensor class in a
+// Llama-style decoder. Everything else (q/k, gate/up, embeddings) uses the base type.
+var ggufReferenceShares = map[ggufTensorClass]float64{
+	ggufAttnV:      0.04,
+	ggufAttnOutput: 0.08,
+	ggufFFNDown:    0.25,
+	ggufOutput:     0.04,
+}
+
+// ggufUpgrade moves a fraction of a tensor class to a larger block type
+type ggufUpgrade struct {
+	class    ggufTensorClass
+	fraction float64
+	block    string
+}
+
+// ggufFileType describes a llama.cpp quantization scheme (e.g. Q4_K_M)
+type ggufFileType struct {
+	name        DataType
+	family      DataTypeFamily
+	base        string
+	upgrades    []ggufUpgrade
+	description string
+}
+
+// bitsPerWeight returns the effective bits per weight of the whole mix
+func (f ggufFileType) bitsPerWeight() float64 {
+	baseShare := 1.0
+	bits := 0.0
+	for _, upgrade := range f.upgrades {
+		share := ggufReferenceShares[upgrade.class] * upgrade.fraction
+		baseShare -= share
+		bits += share * ggufBlockBits[upgrade.block]
+	}
+	return bits + baseShare*ggufBlockBits[f.base]
+}
+
+// outputQ6K is the output tensor upgrade shared by most schemes
+var outputQ6K = ggufUpgrade{ggufOutput, 1, "q6_k"}
+
+// ggufFileTypes mirrors the per-tensor rules of llama.cpp's quantize tool. The _M and _L
+// variants store half (or all) of attn_v/attn_output/ffn_down in a larger block type.
+var ggufFileTypes = []ggufFileType{
+	{name: "q8_0", family: FamilyGGUF, base: "q8_0", description: "GGUF 8-bit"},
+	{name: "q6_k", family: FamilyGGUF, base: "q6_k", description: "GGUF 6-bit k-quant"},
+	{
+		name: "q5_k_m", family: FamilyGGUF, base: "q5_k", description: "GGUF 5-bit k-quant, medium",
+		upgrades: []ggufUpgrade{{ggufAttnV, 0.5, "q6_k"}, {ggufFFNDown, 0.5, "q6_k"}, outputQ6K},
+	},
+	{
+		name: "q5_k_s", family: FamilyGGUF, base: "q5_k", description: "GGUF 5-bit k-quant, small",
+		upgrades: []ggufUpgrade{outputQ6K},
+	},
+	{
+		name: "q5_1", family: FamilyGGUF, base: "q5_1", description: "GGUF legacy 5-bit with min",
+		upgrades: []ggufUpgrade{outputQ6K},
+	},
+	{
+		name: "q5_0", family: FamilyGGUF, base: "q5_0", description: "GGUF legacy 5-bit",
+		upgrades: []ggufUpgrade{outputQ6K},
+	},
+	{
+		name: "q4_k_m", family: FamilyGGUF, base: "q4_k", description: "GGUF 4-bit k-quant, medium",
+		upgrades: []ggufUpgrade{{ggufAttnV, 0.5, "q6_k"}, {ggufFFNDown, 0.5, "q6_k"}, outputQ6K},
+	},
+	{
+		name: "q4_k_s", family: FamilyGGUF, base: "q4_k", description: "GGUF 4-bit k-quant, small",
+		upgrades: []ggufUpgrade{{ggufFFNDown, 0.125, "q5_k"}, outputQ6K},
+	},
+	{
+		name: "q4_1", family: FamilyGGUF, base: "q4_1", description: "GGUF legacy 4-bit with min",
+		upgrades: []ggufUpgrade{outputQ6K},
+	},
+	{
+		name: "q4_0", family: FamilyGGUF, base: "q4_0", description: "GGUF legacy 4-bit",
+		upgrades: []ggufUpgrade{outputQ6K},
+	},
+	{
+		name: "q3_k_l", family: FamilyGGUF, base: "q3_k", description: "GGUF 3-bit k-quant, large",
+		upgrades: []ggufUpgrade{
+			{ggufAttnV, 1, "q5_k"}, {ggufAttnOutput, 1, "q5_k"}, {ggufFFNDown, 1, "q5_k"}, outputQ6K,
+		},
+	},
+	{
+		name: "q3_k_m", family: FamilyGGUF, base: "q3_k", description: "GGUF 3-bit k-quant, medium",
+		upgrades: []ggufUpgrade{
+			{ggufAttnV, 1, "q4_k"}, {ggufAttnOutput, 1, "q4_k"}, {ggufFFNDown, 0.5, "q4_k"}, outputQ6K,
+		},
+	},
+	{
+		name: "q3_k_s", family: FamilyGGUF, base: "q3_k", description: "GGUF 3-bit k-quant, small",
+		upgrades: []ggufUpgrade{outputQ6K},
+	},
+	{
+		name: "q2_k", family: FamilyGGUF, base: "q2_k", description: "GGUF 2-bit k-quant",
+		upgrades: []ggufUpgrade{
+			{ggufAttnV, 1, "q3_k"}, {ggufAttnOutput, 1, "q3_k"}, {ggufFFNDown, 1, "q3_k"}, outputQ6K,
+		},
+	},
+	{
+		name: "iq4_nl", family: FamilyGGUFIQ, base: "iq4_nl", description: "GGUF 4-bit non-linear",
+		upgrades: []ggufUpgrade{outputQ6K},
+	},
+	{
+		name: "iq4_xs", family: FamilyGGUFIQ, base: "iq4_xs", description: "GGUF 4.25-bit i-quant",
+		upgrades: []ggufUpgrade{outputQ6K},
+	},
+	{
+		name: "iq3_m", family: FamilyGGUFIQ, base: "iq3_s", description: "GGUF 3-bit i-quant, medium",
+		upgrades: []ggufUpgrade{{ggufAttnOutput, 1, "q4_k"}, {ggufFFNDown, 0.5, "q4_k"}, outputQ6K},
+	},
+	{
+		name: "iq3_s", family: FamilyGGUFIQ, base: "iq3_s", description: "GGUF 3.4-bit i-quant",
+		upgrades: []ggufUpgrade{outputQ6K},
+	},
+	{
+		name: "iq3_xxs", family: FamilyGGUFIQ, base: "iq3_xxs", description: "GGUF 3-bit i-quant",
+		upgrades: []ggufUpgrade{{ggufAttnV, 1, "q4_k"}, outputQ6K},
+	},
+	{
+		name: "iq2_m", family: FamilyGGUFIQ, base: "iq2_s", description: "GGUF 2.7-bit i-quant",
+		upgrades: []ggufUpgrade{{ggufAttnV, 1, "q4_k"}, {ggufFFNDown, 0.5, "iq3_s"}, {ggufOutput, 1, "q5_k"}},
+	},
+	{
+		name: "iq2_s", family: FamilyGGUFIQ, base: "iq2_s", description: "GGUF 2.5-bit i-quant",
+		upgrades: []ggufUpgrade{{ggufAttnV, 1, "q4_k"}, {ggufOutput, 1, "q5_k"}},
+	},
+	{
+		name: "iq2_xs", family: FamilyGGUFIQ, base: "iq2_xs", description: "GGUF 2.3-bit i-quant",
+		upgrades: []ggufUpgrade{{ggufAttnV, 1, "q4_k"}, {ggufOutput, 1, "q5_k"}},
+	},
+	{
+		name: "iq2_xxs", family: FamilyGGUFIQ, base: "iq2_xxs", description: "GGUF 2-bit i-quant",
+		upgrades: []ggufUpgrade{{ggufAttnV, 1, "q4_k"}, {ggufOutput, 1, "q5_k"}},
+	},
+	{
+		name: "iq1_m", family: FamilyGGUFIQ, base: "iq1_m", description: "GGUF 1.75-bit i-quant",
+		upgrades: []ggufUpgrade{{ggufAttnV, 1, "q4_k"}, {ggufOutput, 1, "q5_k"}},
+	},
+	{
+		name: "iq1_s", family: FamilyGGUFIQ, base: "iq1_s", description: "GGUF 1.56-bit i-quant",
+		upgrades: []ggufUpgrade{{ggufAttnV, 1, "q4_k"}, {ggufOutput, 1, "q5_k"}},
+	},
+}
+
+// ggufDataTypes converts the GGUF file types into registry entries
+func ggufDataTypes() []DataTypeInfo {
+	infos := make([]DataTypeInfo, 0, len(ggufFileTypes))
+	for _, fileType := range ggufFileTypes {
+		infos = append(infos, DataTypeInfo{
+			Name:          fileType.name,
+			Family:        fileType.family,
+			BitsPerWeight: round(fileType.bitsPerWeight(), 4),
+			Description:   fileType.description,
+		})
+	}
+	return infos
+}
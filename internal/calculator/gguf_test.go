@@ -0,0 +1,75 @@
+// internal/calculator/gguf_test.go
+
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+// Published file sizes of the Meta-Llama-3-8B-Instruct GGUF quantizations, which
+// include the metadata and the embeddings at the base type
+func TestGGUFBitsPerWeight(t *testing.T) {
+	const parametersB = 8.03
+	tests := []struct {
+		dtype  DataType
+		fileGB float64
+	}{
+		{"Q8_0", 8.54},
+		{"Q6_K", 6.60},
+		{"Q5_K_M", 5.73},
+		{"Q5_K_S", 5.60},
+		{"Q4_K_M", 4.92},
+		{"Q4_K_S", 4.69},
+		{"IQ4_XS", 4.45},
+		{"Q3_K_L", 4.32},
+		{"Q3_K_M", 4.02},
+		{"IQ3_M", 3.78},
+		{"Q3_K_S", 3.66},
+		{"IQ3_XXS", 3.27},
+		{"Q2_K", 3.18},
+		{"IQ2_M", 2.95},
+	}
+	for _, tt := range tests {
+		bytes, ok := BytesPerParameter(tt.dtype)
+		if !ok {
+			t.Errorf("BytesPerParameter(%q) not supported", tt.dtype)
+			continue
+		}
+		// The mixes are modeled on reference tensor shares, allow 5%
+		if got := bytes * parametersB; math.Abs(got-tt.fileGB) > 0.05*tt.fileGB {
+			t.Errorf("%s: %.2f GB, want about %.2f GB", tt.dtype, got, tt.fileGB)
+		}
+	}
+}
+
+func TestGGUFDataTypes(t *testing.T) {
+	tests := []struct {
+		dtype  DataType
+		name   DataType
+		family DataTypeFamily
+	}{
+		{"Q4_K_M", "q4_k_m", FamilyGGUF},
+		{" q8_0 ", "q8_0", FamilyGGUF},
+		{"IQ2_XXS", "iq2_xxs", FamilyGGUFIQ},
+	}
+	for _, tt := range tests {
+		info, ok := GetDataTypeInfo(tt.dtype)
+		if !ok || info.Name != tt.name || info.Family != tt.family {
+			t.Errorf("GetDataTypeInfo(%q) = %s/%s, %v, want %s/%s", tt.dtype, info.Name, info.Family, ok, tt.name, tt.family)
+		}
+		if ValidateKVDataType(tt.dtype) {
+			t.Errorf("ValidateKVDataType(%q) = true, GGUF types don't quantize the KV cache", tt.dtype)
+		}
+	}
+
+	// Larger mixes of the same base type never get smaller
+	order := []DataType{"q3_k_s", "q3_k_m", "q3_k_l", "q4_k_s", "q4_k_m", "q5_k_s", "q5_k_m", "q6_k", "q8_0"}
+	for i := 1; i < len(order); i++ {
+		smaller, _ := BytesPerParameter(order[i-1])
+		larger, _ := BytesPerParameter(order[i])
+		if larger <= smaller {
+			t.Errorf("%s (%g bytes) is not larger than %s (%g bytes)", order[i], larger, order[i-1], smaller)
+		}
+	}
+}
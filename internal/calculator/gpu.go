@@ -0,0 +1,209 @@
+// internal/calculator/gpu.go
+
+package calculator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// GPU describes an accelerator in the catalog
+type GPU struct {
+	Name         string   `json:"name"`
+	Aliases      []string `json:"aliases,omitempty"`
+	MemoryGB     float64  `json:"memory_gb"`
+	BandwidthGBs float64  `json:"bandwidth_gbs"`
+	FP16         bool     `json:"fp16"`
+	FP8          bool     `json:"fp8"`
+}
+
+// gpuCatalog holds the built-in GPUs followed by any user-defined ones
+var gpuCatalog = []GPU{
+	{Name: "T4", MemoryGB: 16, BandwidthGBs: 320, FP16: true},
+	{Name: "V100-16G", MemoryGB: 16, BandwidthGBs: 900, FP16: true},
+	{Name: "V100-32G", Aliases: []string{"V100"}, MemoryGB: 32, BandwidthGBs: 900, FP16: true},
+	{Name: "L4", MemoryGB: 24, BandwidthGBs: 300, FP16: true, FP8: true},
+	{Name: "A10G", MemoryGB: 24, BandwidthGBs: 600, FP16: true},
+	{Name: "A10", MemoryGB: 24, BandwidthGBs: 600, FP16: true},
+	{Name: "RTX-3090", Aliases: []string{"3090"}, MemoryGB: 24, BandwidthGBs: 936, FP16: true},
+	{Name: "RTX-4090", Aliases: []string{"4090"}, MemoryGB: 24, BandwidthGBs: 1008, FP16: true, FP8: true},
+	{Name: "A40", MemoryGB: 48, BandwidthGBs: 696, FP16: true},
+	{Name: "RTX-A6000", Aliases: []string{"A6000"}, MemoryGB: 48, BandwidthGBs: 768, FP16: true},
+	{Name: "L40S", MemoryGB: 48, BandwidthGBs: 864, FP16: true, FP8: true},
+	{Name: "A100-40G", MemoryGB: 40, BandwidthGBs: 1555, FP16: true},
+	{Name: "A100-80G", Aliases: []string{"A100"}, MemoryGB: 80, BandwidthGBs: 2039, FP16: true},
+	{Name: "H100-80G", Aliases: []string{"H100", "H100-SXM"}, MemoryGB: 80, BandwidthGBs: 3350, FP16: true, FP8: true},
+	{Name: "H100-PCIe", MemoryGB: 80, BandwidthGBs: 2000, FP16: true, FP8: true},
+	{Name: "H100-NVL", MemoryGB: 94, BandwidthGBs: 3900, FP16: true, FP8: true},
+	{Name: "H200", MemoryGB: 141, BandwidthGBs: 4800, FP16: true, FP8: true},
+	{Name: "B200", MemoryGB: 192, BandwidthGBs: 8000, FP16: true, FP8: true},
+	{Name: "MI300X", MemoryGB: 192, BandwidthGBs: 5300, FP16: true, FP8: true},
+}
+
+// GetGPUs returns the GPU catalog
+func GetGPUs() []GPU {
+	return gpuCatalog
+}
+
+// LookupGPU finds a GPU by name or alias, ignoring case
+func LookupGPU(name string) (GPU, bool) {
+	for _, gpu := range gpuCatalog {
+		if strings.EqualFold(gpu.Name, name) {
+			return gpu, true
+		}
+		for _, alias := range gpu.Aliases {
+			if strings.EqualFold(alias, name) {
+				return gpu, true
+			}
+		}
+	}
+	return GPU{}, false
+}
+
+// DefaultGPUCatalogPath returns the location of the user's GPU catalog
+func DefaultGPUCatalogPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "huggyfit", "gpus.json")
+}
+
+// LoadGPUCatalog adds the GPUs from a JSON file to the catalog. Entries with
+// the name of an existing GPU replace it.
+func LoadGPUCatalog(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read GPU catalog: %w", err)
+	}
+
+	var gpus []GPU
+	if err := json.Unmarshal(data, &gpus); err != nil {
+		return fmt.Errorf("failed to parse GPU catalog %s: %w", path, err)
+	}
+
+	for _, gpu := range gpus {
+		if gpu.Name == "" || gpu.MemoryGB <= 0 {
+			return fmt.Errorf("invalid GPU in catalog %s: name and memory_gb are required", path)
+		}
+		replaced := false
+		for i := range gpuCatalog {
+			if strings.EqualFold(gpuCatalog[i].Name, gpu.Name) {
+				gpuCatalog[i] = gpu
+				replaced = true
+			}
+		}
+		if !replaced {
+			gpuCatalog = append(gpuCatalog, gpu)
+		}
+	}
+	return nil
+}
+
+// LoadDefaultGPUCatalog loads the user's GPU catalog if one exists
+func LoadDefaultGPUCatalog() error {
+	path := DefaultGPUCatalogPath()
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return LoadGPUCatalog(path)
+}
+
+// Supports reports whether the GPU natively computes in the data type
+func (g GPU) Supports(dtype DataType) bool {
+	switch NormalizeDataType(dtype) {
+	case FP8E4M3, FP8E5M2:
+		return g.FP8
+	case Float16, BFloat16:
+		return g.FP16
+	default:
+		return true
+	}
+}
+
+// GPUSpec is a number of identical GPUs, e.g. "2xA100-80G"
+type GPUSpec struct {
+	Count int
+	GPU   GPU
+}
+
+// ParseGPUSpec parses a spec like "H100", "2xA100-80G" or "4x L4"
+func ParseGPUSpec(spec string) (GPUSpec, error) {
+	spec = strings.TrimSpace(spec)
+	count := 1
+	name := spec
+	if prefix, rest, found := strings.Cut(strings.ToLower(spec), "x"); found {
+		if n, err := strconv.Atoi(strings.TrimSpace(prefix)); err == nil {
+			if n < 1 {
+				return GPUSpec{}, fmt.Errorf("invalid GPU count in %q", spec)
+			}
+			count = n
+			name = strings.TrimSpace(spec[len(spec)-len(rest):])
+		}
+	}
+
+	gpu, ok := LookupGPU(name)
+	if !ok {
+		return GPUSpec{}, fmt.Errorf("unknown GPU %q", name)
+	}
+	return GPUSpec{Count: count, GPU: gpu}, nil
+}
+
+// String formats the spec, e.g. "2x A100-80G"
+func (s GPUSpec) String() string {
+	return fmt.Sprintf("%dx %s", s.Count, s.GPU.Name)
+}
+
+// TotalMemoryGB returns the combined memory of all GPUs in the spec
+func (s GPUSpec) TotalMemoryGB() float64 {
+	return float64(s.Count) * s.GPU.MemoryGB
+}
+
+// GPUFit is the verdict for the GPUs sharing one breakdown
+type GPUFit struct {
+	Label      string
+	RequiredGB float64
+	HeadroomGB float64 // Negative when the GPU runs out of memory
+}
+
+// Fits reports whether the required memory fits on the GPU
+func (f GPUFit) Fits() bool {
+	return f.HeadroomGB >= 0
+}
+
+// FitResult is the verdict for a whole deployment
+type FitResult struct {
+	Spec   GPUSpec
+	PerGPU []GPUFit
+}
+
+// Fits reports whether every GPU has enough memory
+func (r FitResult) Fits() bool {
+	for _, fit := range r.PerGPU {
+		if !fit.Fits() {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckFit compares per-GPU memory requirements against the GPUs in the spec
+func CheckFit(spec GPUSpec, perGPU []MemoryBreakdown) FitResult {
+	result := FitResult{Spec: spec}
+	for _, b := range perGPU {
+		result.PerGPU = append(result.PerGPU, GPUFit{
+			Label:      b.Label,
+			RequiredGB: b.Total(),
+			HeadroomGB: spec.GPU.MemoryGB - b.Total(),
+		})
+	}
+	return result
+}
@@ -0,0 +1,127 @@
+// internal/calculator/gpu_test.go
+
+package calculator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseGPUSpec(t *testing.T) {
+	tests := []struct {
+		spec     string
+		count    int
+		name     string
+		memoryGB float64
+	}{
+		{"H100", 1, "H100-80G", 80},
+		{"h100-sxm", 1, "H100-80G", 80},
+		{"2xA100-80G", 2, "A100-80G", 80},
+		{"4x L4", 4, "L4", 24},
+		{" 8xH200 ", 8, "H200", 141},
+		{"A100-40G", 1, "A100-40G", 40},
+		{"4090", 1, "RTX-4090", 24},
+		{"MI300X", 1, "MI300X", 192},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			spec, err := ParseGPUSpec(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseGPUSpec() error = %v", err)
+			}
+			if spec.Count != tt.count || spec.GPU.Name != tt.name || spec.GPU.MemoryGB != tt.memoryGB {
+				t.Errorf("ParseGPUSpec() = %d x %s (%.0f GB), want %d x %s (%.0f GB)",
+					spec.Count, spec.GPU.Name, spec.GPU.MemoryGB, tt.count, tt.name, tt.memoryGB)
+			}
+			if got, want := spec.TotalMemoryGB(), float64(tt.count)*tt.memoryGB; got != want {
+				t.Errorf("TotalMemoryGB() = %.0f, want %.0f", got, want)
+			}
+		})
+	}
+
+	for _, spec := range []string{"", "TPU-v5", "0xH100", "2xRTX-9090"} {
+		if _, err := ParseGPUSpec(spec); err == nil {
+			t.Errorf("ParseGPUSpec(%q) expected an error", spec)
+		}
+	}
+}
+
+func TestGPUSupports(t *testing.T) {
+	h100, _ := LookupGPU("H100")
+	a100, _ := LookupGPU("A100")
+
+	tests := []struct {
+		gpu   GPU
+		dtype DataType
+		want  bool
+	}{
+		{h100, FP8, true},
+		{a100, FP8, false},
+		{a100, "fp8_e5m2", false},
+		{a100, BF16, true},
+		{a100, Int4, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.gpu.Supports(tt.dtype); got != tt.want {
+			t.Errorf("%s.Supports(%s) = %v, want %v", tt.gpu.Name, tt.dtype, got, tt.want)
+		}
+	}
+}
+
+func TestLoadGPUCatalog(t *testing.T) {
+	saved := append([]GPU(nil), gpuCatalog...)
+	t.Cleanup(func() { gpuCatalog = saved })
+
+	path := filepath.Join(t.TempDir(), "gpus.json")
+	catalog := `[
+		{"name": "L4", "memory_gb": 22},
+		{"name": "RTX-5090", "aliases": ["5090"], "memory_gb": 32, "fp16": true, "fp8": true}
+	]`
+	if err := os.WriteFile(path, []byte(catalog), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadGPUCatalog(path); err != nil {
+		t.Fatalf("LoadGPUCatalog() error = %v", err)
+	}
+
+	if gpu, _ := LookupGPU("L4"); gpu.MemoryGB != 22 {
+		t.Errorf("L4 memory = %.0f GB, want the replaced 22 GB", gpu.MemoryGB)
+	}
+	if gpu, ok := LookupGPU("5090"); !ok || gpu.MemoryGB != 32 {
+		t.Errorf("LookupGPU(5090) = %v, %v, want the added RTX-5090", gpu, ok)
+	}
+	if len(gpuCatalog) != len(saved)+1 {
+		t.Errorf("catalog has %d GPUs, want %d", len(gpuCatalog), len(saved)+1)
+	}
+
+	if err := os.WriteFile(path, []byte(`[{"name": "X"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadGPUCatalog(path); err == nil {
+		t.Error("expected an error for a GPU without memory_gb")
+	}
+}
+
+func TestCheckFit(t *testing.T) {
+	spec, _ := ParseGPUSpec("2xL4")
+	result := CheckFit(spec, []MemoryBreakdown{
+		{Label: "stage 0", WeightsGB: 16, KVCacheGB: 6},
+		{Label: "stage 1", WeightsGB: 16, KVCacheGB: 9},
+	})
+
+	if len(result.PerGPU) != 2 {
+		t.Fatalf("got %d verdicts, want 2", len(result.PerGPU))
+	}
+	if fit := result.PerGPU[0]; !fit.Fits() || fit.HeadroomGB != 2 {
+		t.Errorf("stage 0 headroom = %.2f GB, want 2 GB", fit.HeadroomGB)
+	}
+	if fit := result.PerGPU[1]; fit.Fits() || fit.HeadroomGB != -1 {
+		t.Errorf("stage 1 headroom = %.2f GB, want -1 GB", fit.HeadroomGB)
+	}
+	if result.Fits() {
+		t.Error("Fits() = true with a stage over the L4's 24 GB")
+	}
+}
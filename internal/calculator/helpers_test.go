@@ -0,0 +1,81 @@
+// internal/calculator/helpers_test.go
+
+package calculator
+
+import "testing"
+
+// llama3Config returns the config of meta-llama/Meta-Llama-3-8B
+func llama3Config() *ModelConfig {
+	return &ModelConfig{
+		ModelType:         "llama",
+		HiddenSize:        4096,
+		NumAttentionHeads: 32,
+		NumHiddenLayers:   32,
+		NumKeyValueHeads:  8,
+		IntermediateSize:  14336,
+		VocabSize:         128256,
+
+		MaxPositionEmbeddings: 8192,
+	}
+}
+
+// mixtralConfig returns the config of mistralai/Mixtral-8x7B-v0.1
+func mixtralConfig() *ModelConfig {
+	return &ModelConfig{
+		ModelType:         "mixtral",
+		HiddenSize:        4096,
+		NumAttentionHeads: 32,
+		NumHiddenLayers:   32,
+		NumKeyValueHeads:  8,
+		IntermediateSize:  14336,
+		NumLocalExperts:   8,
+		NumExpertsPerTok:  2,
+	}
+}
+
+// mambaConfig returns the config of state-spaces/mamba-2.8b-hf, which has no
+// attention layers
+func mambaConfig() *ModelConfig {
+	return &ModelConfig{
+		ModelType:       "mamba",
+		HiddenSize:      2560,
+		NumHiddenLayers: 64,
+		StateSize:       16,
+		ConvKernel:      4,
+		Expand:          2,
+		VocabSize:       50280,
+	}
+}
+
+// jambaConfig returns the config of ai21labs/Jamba-v0.1, with one attention
+// layer in every eight and experts in every other layer
+func jambaConfig() *ModelConfig {
+	return &ModelConfig{
+		ModelType:         "jamba",
+		HiddenSize:        4096,
+		NumAttentionHeads: 32,
+		NumHiddenLayers:   32,
+		NumKeyValueHeads:  8,
+		IntermediateSize:  14336,
+		VocabSize:         65536,
+		NumExperts:        16,
+		NumExpertsPerTok:  2,
+		ExpertLayerPeriod: 2,
+		ExpertLayerOffset: 1,
+		AttnLayerPeriod:   8,
+		AttnLayerOffset:   4,
+		MambaDState:       16,
+		MambaDConv:        4,
+		MambaExpand:       2,
+	}
+}
+
+// mustParseConfig parses a config.json the way FetchModelConfig does
+func mustParseConfig(t *testing.T, data string) *ModelConfig {
+	t.Helper()
+	config, err := ParseModelConfig([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseModelConfig: %v", err)
+	}
+	return config
+}
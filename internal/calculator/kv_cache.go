@@ -2,67 +2,56 @@
 
 package calculator
 
-import (
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"time"
-)
-
-// ModelConfig represents the relevant fields from config.json
-type ModelConfig struct {
-	HiddenSize        int `json:"hidden_size"`
-	NumAttentionHeads int `json:"num_attention_heads"`
-	NumHiddenLayers   int `json:"num_hidden_layers"`
-	NumKeyValueHeads  int `json:"num_key_value_heads"`
-}
+import "fmt"
+
+// bytesPerGiB converts KV cache sizes in bytes to gigabytes
+const bytesPerGiB = 1024 * 1024 * 1024
+
+// bytesPerFloat32 is the size of the fp32 logits, buffers and training states
+const bytesPerFloat32 = 4
 
 // KVCacheParams holds parameters for KV cache calculation
 type KVCacheParams struct {
 	Users         int
 	ContextLength int
-	DataType      DataType
+	DataType      DataType // Data type of the model weights
+	KVDataType    DataType // Data type of the KV cache, defaults to float16
+	ImageTokens   int      // Vision tokens per user, see ModelConfig.ImageTokens
 	Config        *ModelConfig
-}
-
-// FetchModelConfig retrieves the model's configuration from HuggingFace
-func FetchModelConfig(modelID string) (*ModelConfig, error) {
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	url := fmt.Sprintf("https://huggingface.co/%s/raw/main/config.json", modelID)
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch model config: %w", err)
-	}
-	defer resp.Body.Close()
+	Strict        bool // Return ErrEstimateRefused instead of estimating without a config
 
-	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response: %w", err)
-		}
-		return nil, fmt.Errorf("\n%s", string(body))
-	}
+	// Encoder-decoder models, see EncoderDecoderLengths
+	EncoderInputLength  int // Encoder tokens cached by cross-attention
+	DecoderOutputLength int // Decoder tokens cached by self-attention
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config response: %w", err)
-	}
+// sequenceTokens are the tokens of one sequence cached by self-attention and
+// cross-attention layers
+type sequenceTokens struct {
+	self  int
+	cross int
+}
 
-	var config ModelConfig
-	if err := json.Unmarshal(body, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config: %w", err)
+// sequence returns the tokens each user caches. Image tokens join the context
+// unless the model attends to them through cross-attention layers, and the
+// decoder of an encoder-decoder model cross-attends to the encoder input.
+func (p KVCacheParams) sequence() sequenceTokens {
+	switch {
+	case p.Config != nil && p.Config.IsEncoderDecoder:
+		encoder, decoder := p.EncoderDecoderLengths()
+		return sequenceTokens{self: decoder, cross: encoder}
+	case p.Config != nil && p.Config.hasCrossAttention():
+		return sequenceTokens{self: p.ContextLength, cross: p.ImageTokens}
 	}
+	return sequenceTokens{self: p.ContextLength + p.ImageTokens}
+}
 
-	// Handle models that don't specify num_key_value_heads
-	if config.NumKeyValueHeads == 0 {
-		config.NumKeyValueHeads = config.NumAttentionHeads
+// kvDataType returns the KV cache data type, falling back to float16
+func (p KVCacheParams) kvDataType() DataType {
+	if p.KVDataType == "" {
+		return Float16
 	}
-
-	return &config, nil
+	return NormalizeDataType(p.KVDataType)
 }
 
 // CalculateKVCache computes memory required for KV cache per user
@@ -70,21 +59,28 @@ func CalculateKVCache(params KVCacheParams) (float64, error) {
 	if params.Config == nil {
 		return 0, fmt.Errorf("model config is required for KV cache calculation")
 	}
-
-	bytes, ok := BytesPerType[params.DataType]
-	if !ok {
-		return 0, ErrUnsupportedDataType{params.DataType}
+	if err := params.Config.Validate(); err != nil {
+		return 0, err
 	}
 
-	// KV Cache formula:
-	// Memory = 2 * num_layers * seq_len * (hidden_size/num_attn_heads * num_kv_heads) * 2 * bytes_per_param * num_users
-	kvSize := float64(2 * params.Config.NumHiddenLayers * params.ContextLength *
-		(params.Config.HiddenSize / params.Config.NumAttentionHeads) *
-		params.Config.NumKeyValueHeads * 2)
+	kvDataType := params.kvDataType()
+	if !ValidateKVDataType(kvDataType) {
+		return 0, ErrUnsupportedDataType{kvDataType}
+	}
+	bytes, _ := BytesPerParameter(kvDataType)
+
+	// KV Cache formula, summed over layers:
+	// Memory = cached_tokens * elements_per_token * bytes_per_element * num_users
+	// where cached_tokens is capped by the sliding window on local layers, is the
+	// image tokens on cross-attention layers, the encoder input for the
+	// cross-attention of encoder-decoder models, and elements_per_token is
+	// 2 * num_kv_heads * head_dim, or the latent size for MLA. State-space
+	// layers add their fixed-size recurrent state in the model's data type instead.
+	kvSize := params.Config.kvCacheElements(params.sequence())
+	stateSize := params.Config.stateElementsPerRank(0, params.Config.NumHiddenLayers, 1)
 
 	// Convert to GB
-	const BytesPerGB = 1024 * 1024 * 1024
-	memoryGB := (kvSize * bytes) / BytesPerGB
+	memoryGB := (kvSize*bytes + stateSize*params.stateBytes()) / bytesPerGiB
 
 	// Apply per-user scaling
 	totalMemoryGB := memoryGB * float64(params.Users)
@@ -92,29 +88,48 @@ func CalculateKVCache(params KVCacheParams) (float64, error) {
 	return round(totalMemoryGB, 2), nil
 }
 
-// EstimateKVCache provides an estimation for gated models
+// kvCacheGB calculates the KV cache precisely when the config is known and
+// falls back to the estimate otherwise, unless the calculation is strict
+func kvCacheGB(parametersB float64, params KVCacheParams) (float64, error) {
+	if params.Config == nil {
+		if params.Strict {
+			return 0, ErrEstimateRefused{"no model config"}
+		}
+		return EstimateKVCache(parametersB, params.Users, params.ContextLength+params.ImageTokens, params.KVDataType), nil
+	}
+	return CalculateKVCache(params)
+}
+
+// EstimateKVCache provides an estimation for gated models.
+// The dtype is the KV cache data type, not the weight data type.
 func EstimateKVCache(parameterCount float64, users, contextLength int, dtype DataType) float64 {
+	memoryPerUser := estimatedKVCachePerToken(parameterCount, dtype) * float64(contextLength)
+	return round(memoryPerUser*float64(users), 2)
+}
+
+// estimatedKVCachePerToken returns the estimated KV cache per token in GB
+func estimatedKVCachePerToken(parameterCount float64, dtype DataType) float64 {
 	// Estimation based on model size:
 	// Small (< 7B): ~0.5GB per 1k tokens
 	// Medium (7-20B): ~1GB per 1k tokens
 	// Large (> 20B): ~2GB per 1k tokens
-	var memoryPerUser float64
+	var memoryPer1k float64
 	switch {
 	case parameterCount < 7:
-		memoryPerUser = 0.5
+		memoryPer1k = 0.5
 	case parameterCount < 20:
-		memoryPerUser = 1.0
+		memoryPer1k = 1.0
 	default:
-		memoryPerUser = 2.0
+		memoryPer1k = 2.0
 	}
 
-	// Scale by context length (normalized to 1k tokens)
-	memoryPerUser *= float64(contextLength) / 1000.0
-
 	// Apply dtype scaling
-	bytes, _ := BytesPerType[dtype]
+	if dtype == "" {
+		dtype = Float16
+	}
+	bytes, _ := BytesPerParameter(dtype)
 	dtypeScale := bytes / BytesPerType[Float16] // normalize to FP16
-	memoryPerUser *= dtypeScale
 
-	return round(memoryPerUser*float64(users), 2)
+	// Normalized to 1k tokens
+	return memoryPer1k * dtypeScale / 1000.0
 }
@@ -0,0 +1,149 @@
+// internal/calculator/kv_cache_test.go
+
+package calculator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCalculateKVCacheDataType(t *testing.T) {
+	base := KVCacheParams{Users: 4, ContextLength: 8192, DataType: Float16, Config: llama3Config()}
+	fp16, err := CalculateKVCache(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		weights  DataType
+		kv       DataType
+		fraction float64 // Of the float16 KV cache
+	}{
+		{"defaults to float16", Int4, "", 1},
+		{"bfloat16", BFloat16, BF16, 1},
+		{"fp8 halves the cache", BFloat16, FP8, 0.5},
+		{"int8 under int4 weights", Int4, Int8, 0.5},
+		{"int4", Float16, Q4, 0.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := base
+			params.DataType, params.KVDataType = tt.weights, tt.kv
+			got, err := CalculateKVCache(params)
+			if err != nil {
+				t.Fatalf("CalculateKVCache: %v", err)
+			}
+			if want := round(fp16*tt.fraction, 2); got != want {
+				t.Errorf("CalculateKVCache() = %.2f GB, want %.2f GB", got, want)
+			}
+		})
+	}
+
+	params := base
+	params.KVDataType = "int3"
+	if _, err := CalculateKVCache(params); !errors.As(err, new(ErrUnsupportedDataType)) {
+		t.Errorf("CalculateKVCache(int3) = %v, want ErrUnsupportedDataType", err)
+	}
+}
+
+func TestEstimateKVCacheDataType(t *testing.T) {
+	fp16 := EstimateKVCache(8, 1, 8192, Float16)
+	if got := EstimateKVCache(8, 1, 8192, ""); got != fp16 {
+		t.Errorf("EstimateKVCache without a data type = %.2f GB, want the float16 %.2f GB", got, fp16)
+	}
+	if got, want := EstimateKVCache(8, 1, 8192, Float8), round(fp16/2, 2); got != want {
+		t.Errorf("EstimateKVCache(fp8) = %.2f GB, want %.2f GB", got, want)
+	}
+}
+
+func TestCalculateKVCacheLayerKinds(t *testing.T) {
+	const (
+		bf16        = 2.0
+		bytesPerGiB = 1 << 30
+	)
+	off, on := false, true
+
+	tests := []struct {
+		name   string
+		config *ModelConfig
+		params KVCacheParams
+		want   float64 // Bytes for all users
+	}{
+		{
+			// 128 KiB per token: 1 GiB for an 8K context
+			name:   "full attention",
+			config: llama3Config(),
+			params: KVCacheParams{Users: 1, ContextLength: 8192},
+			want:   bytesPerGiB,
+		},
+		{
+			name: "sliding window on every other layer",
+			config: &ModelConfig{ModelType: "gemma2", HiddenSize: 3584, NumHiddenLayers: 42,
+				NumAttentionHeads: 16, NumKeyValueHeads: 8, HeadDim: 256, SlidingWindow: 4096},
+			params: KVCacheParams{Users: 2, ContextLength: 8192},
+			want:   2 * (21*8192 + 21*4096) * 2 * 8 * 256 * bf16,
+		},
+		{
+			name: "five local layers per global layer",
+			config: &ModelConfig{ModelType: "gemma3_text", HiddenSize: 2560, NumHiddenLayers: 34,
+				NumAttentionHeads: 8, NumKeyValueHeads: 4, HeadDim: 256, SlidingWindow: 1024},
+			params: KVCacheParams{Users: 1, ContextLength: 32768},
+			want:   (5*32768 + 29*1024) * 2 * 4 * 256 * bf16,
+		},
+		{
+			name: "explicit layer types",
+			config: &ModelConfig{ModelType: "gpt_oss", HiddenSize: 2880, NumHiddenLayers: 4,
+				NumAttentionHeads: 64, NumKeyValueHeads: 8, HeadDim: 64, SlidingWindow: 128,
+				LayerTypes: []string{"sliding_attention", "full_attention", "sliding_attention", "full_attention"}},
+			params: KVCacheParams{Users: 1, ContextLength: 4096},
+			want:   (2*4096 + 2*128) * 2 * 8 * 64 * bf16,
+		},
+		{
+			name: "sliding window longer than the context",
+			config: &ModelConfig{ModelType: "mistral", HiddenSize: 4096, NumHiddenLayers: 32,
+				NumAttentionHeads: 32, NumKeyValueHeads: 8, SlidingWindow: 4096},
+			params: KVCacheParams{Users: 1, ContextLength: 2048},
+			want:   32 * 2048 * 2 * 8 * 128 * bf16,
+		},
+		{
+			name: "opt-in sliding window left off",
+			config: &ModelConfig{ModelType: "qwen2", HiddenSize: 3584, NumHiddenLayers: 28,
+				NumAttentionHeads: 28, NumKeyValueHeads: 4, SlidingWindow: 4096, UseSlidingWindow: &off},
+			params: KVCacheParams{Users: 1, ContextLength: 32768},
+			want:   28 * 32768 * 2 * 4 * 128 * bf16,
+		},
+		{
+			name: "opt-in sliding window above max_window_layers",
+			config: &ModelConfig{ModelType: "qwen2", HiddenSize: 3584, NumHiddenLayers: 28,
+				NumAttentionHeads: 28, NumKeyValueHeads: 4, SlidingWindow: 4096, UseSlidingWindow: &on,
+				MaxWindowLayers: 21},
+			params: KVCacheParams{Users: 1, ContextLength: 32768},
+			want:   (21*32768 + 7*4096) * 2 * 4 * 128 * bf16,
+		},
+		{
+			// deepseek-ai/DeepSeek-V2-Lite
+			name: "multi-head latent attention",
+			config: &ModelConfig{ModelType: "deepseek_v2", HiddenSize: 2048, NumHiddenLayers: 27,
+				NumAttentionHeads: 16, NumKeyValueHeads: 16, KVLoraRank: 512, QKRopeHeadDim: 64},
+			params: KVCacheParams{Users: 1, ContextLength: 32768},
+			want:   27 * 32768 * (512 + 64) * bf16,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			params.Config = tt.config
+			params.KVDataType = BFloat16
+
+			got, err := CalculateKVCache(params)
+			if err != nil {
+				t.Fatalf("CalculateKVCache: %v", err)
+			}
+			if want := round(tt.want/bytesPerGiB, 2); got != want {
+				t.Errorf("CalculateKVCache() = %.2f GB, want %.2f GB", got, want)
+			}
+		})
+	}
+}
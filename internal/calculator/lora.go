@@ -0,0 +1,183 @@
+// internal/calculator/lora.go
+
+package calculator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LoRAParams describes LoRA adapters on the linear layers of a model
+type LoRAParams struct {
+	Rank          int
+	TargetModules []string // Module names as in PEFT's target_modules, all linear layers when empty
+}
+
+// LoRA target modules of Llama-style decoder layers
+var loraModules = []string{"q_proj", "k_proj", "v_proj", "o_proj", "gate_proj", "up_proj", "down_proj"}
+
+// loraModuleGroups expands shorthand target names to modules
+var loraModuleGroups = map[string][]string{
+	"all-linear": loraModules,
+	"attention":  loraModules[:4],
+	"mlp":        loraModules[4:],
+}
+
+// ParseLoRATargets parses a comma-separated list of target modules or groups
+func ParseLoRATargets(s string) ([]string, error) {
+	var targets []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		modules, ok := loraModuleGroups[name]
+		if !ok {
+			modules = []string{name}
+		}
+		for _, module := range modules {
+			if !isLoRAModule(module) {
+				return nil, fmt.Errorf("unknown LoRA target module %q (%s)", name, DescribeLoRATargets())
+			}
+			if !seen[module] {
+				seen[module] = true
+				targets = append(targets, module)
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no LoRA target modules given")
+	}
+	return targets, nil
+}
+
+// DescribeLoRATargets lists the supported target modules and groups
+func DescribeLoRATargets() string {
+	return "all-linear, attention, mlp, " + strings.Join(loraModules, ", ")
+}
+
+// isLoRAModule reports whether a module can be targeted
+func isLoRAModule(module string) bool {
+	for _, known := range loraModules {
+		if module == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the rank and target modules
+func (p LoRAParams) Validate() error {
+	if p.Rank < 1 {
+		return fmt.Errorf("LoRA rank must be at least 1")
+	}
+	for _, module := range p.TargetModules {
+		if !isLoRAModule(module) {
+			return fmt.Errorf("unknown LoRA target module %q", module)
+		}
+	}
+	return nil
+}
+
+// targets returns the target modules, defaulting to all linear layers
+func (p LoRAParams) targets() []string {
+	if len(p.TargetModules) == 0 {
+		return loraModules
+	}
+	return p.TargetModules
+}
+
+// CalculateLoRAParameters returns the parameters of one adapter. Each targeted
+// linear layer of shape in × out gains rank × (in + out) parameters, and MoE
+// layers get an adapter on the MLP projections of every expert.
+func CalculateLoRAParameters(config *ModelConfig, params LoRAParams) (int64, error) {
+	if config == nil {
+		return 0, fmt.Errorf("model config is required for LoRA adapter size calculation")
+	}
+	if err := params.Validate(); err != nil {
+		return 0, err
+	}
+
+	moeLayers := 0
+	if config.IsMoE() {
+		moeLayers = min(config.moeLayers(), config.NumHiddenLayers)
+	}
+	denseLayers := config.NumHiddenLayers - moeLayers
+
+	var total int64
+	for _, module := range params.targets() {
+		total += int64(denseLayers) * config.loraModuleParameters(module, params.Rank, false)
+		total += int64(moeLayers) * config.loraModuleParameters(module, params.Rank, true)
+	}
+	return total, nil
+}
+
+// loraModuleParameters returns the adapter parameters of a module in one layer
+func (c *ModelConfig) loraModuleParameters(module string, rank int, moe bool) int64 {
+	hidden := c.HiddenSize
+	attention := c.NumAttentionHeads * c.headDim()
+	kv := c.NumKeyValueHeads * c.headDim()
+
+	var width int64
+	switch module {
+	case "q_proj", "o_proj":
+		width = int64(hidden + attention)
+	case "k_proj", "v_proj":
+		width = int64(hidden + kv)
+	case "gate_proj", "up_proj", "down_proj":
+		width = c.loraMLPWidth(moe)
+	}
+	return int64(rank) * width
+}
+
+// loraMLPWidth returns in + out summed over the copies of an MLP projection in
+// a layer: one in dense layers, one per routed and shared expert in MoE layers
+func (c *ModelConfig) loraMLPWidth(moe bool) int64 {
+	hidden := int64(c.HiddenSize)
+	if !moe {
+		return hidden + int64(c.IntermediateSize)
+	}
+
+	expertSize := int64(c.MoEIntermediateSize)
+	if expertSize == 0 {
+		expertSize = int64(c.IntermediateSize)
+	}
+	width := int64(c.numExperts()) * (hidden + expertSize)
+	if c.SharedExpertIntermediateSize > 0 {
+		return width + hidden + int64(c.SharedExpertIntermediateSize)
+	}
+	return width + int64(c.NSharedExperts)*(hidden+expertSize)
+}
+
+// LoRAServingParams describes the adapters a serving engine keeps resident, as
+// vLLM's --max-loras and --max-lora-rank
+type LoRAServingParams struct {
+	LoRA     LoRAParams // Rank is the largest adapter rank, every slot is allocated at it
+	MaxLoRAs int        // Adapters resident on the GPU at once
+}
+
+// adapterDataType returns the data type adapters are served in: the weight data
+// type for 16- and 32-bit floats, float16 for quantized base weights
+func adapterDataType(dtype DataType) DataType {
+	switch dtype = NormalizeDataType(dtype); dtype {
+	case Float32, Float16, BFloat16:
+		return dtype
+	default:
+		return Float16
+	}
+}
+
+// CalculateLoRAServingMemory returns the GB of adapter weights served next to
+// base weights in dtype: MaxLoRAs slots of the adapter size at the maximum rank
+func CalculateLoRAServingMemory(config *ModelConfig, params LoRAServingParams, dtype DataType) (float64, error) {
+	if params.MaxLoRAs < 1 {
+		return 0, fmt.Errorf("number of resident LoRA adapters must be at least 1")
+	}
+	parameters, err := CalculateLoRAParameters(config, params.LoRA)
+	if err != nil {
+		return 0, err
+	}
+	bytes, _ := BytesPerParameter(adapterDataType(dtype))
+	return round(float64(parameters)*bytes*float64(params.MaxLoRAs)/1e9, 2), nil
+}
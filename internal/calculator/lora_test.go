@@ -0,0 +1,114 @@
+// internal/calculator/lora_test.go
+
+package calculator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCalculateLoRAParameters(t *testing.T) {
+	// meta-llama/Llama-2-7b-hf
+	llama2 := &ModelConfig{ModelType: "llama", HiddenSize: 4096, NumAttentionHeads: 32,
+		NumHiddenLayers: 32, NumKeyValueHeads: 32, IntermediateSize: 11008, VocabSize: 32000}
+
+	// Trainable parameter counts as printed by PEFT's print_trainable_parameters
+	tests := []struct {
+		name   string
+		config *ModelConfig
+		params LoRAParams
+		want   int64
+	}{
+		{"llama2 r=8 q and v", llama2, LoRAParams{Rank: 8, TargetModules: []string{"q_proj", "v_proj"}}, 4194304},
+		{"llama3 r=16 attention", llama3Config(), LoRAParams{Rank: 16, TargetModules: loraModules[:4]}, 13631488},
+		{"llama3 r=16 all linear", llama3Config(), LoRAParams{Rank: 16}, 41943040},
+		{"llama3 r=64 all linear", llama3Config(), LoRAParams{Rank: 64}, 167772160},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CalculateLoRAParameters(tt.config, tt.params)
+			if err != nil {
+				t.Fatalf("CalculateLoRAParameters() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CalculateLoRAParameters() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	// Every expert of a MoE layer gets its own MLP adapters
+	mlp, err := CalculateLoRAParameters(mixtralConfig(), LoRAParams{Rank: 8, TargetModules: []string{"gate_proj"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(32 * 8 * 8 * (4096 + 14336)); mlp != want {
+		t.Errorf("Mixtral gate_proj adapters = %d, want %d", mlp, want)
+	}
+
+	if _, err := CalculateLoRAParameters(llama2, LoRAParams{Rank: 0}); err == nil {
+		t.Error("expected an error for rank 0")
+	}
+	if _, err := CalculateLoRAParameters(nil, LoRAParams{Rank: 8}); err == nil {
+		t.Error("expected an error without a model config")
+	}
+}
+
+func TestParseLoRATargets(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"q_proj,v_proj", []string{"q_proj", "v_proj"}},
+		{" Q_PROJ , attention ", []string{"q_proj", "k_proj", "v_proj", "o_proj"}},
+		{"mlp", []string{"gate_proj", "up_proj", "down_proj"}},
+		{"all-linear", loraModules},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLoRATargets(tt.input)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseLoRATargets(%q) = %v, %v, want %v", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", " , ", "lm_head", "q_proj,embed_tokens"} {
+		if _, err := ParseLoRATargets(input); err == nil {
+			t.Errorf("ParseLoRATargets(%q) expected an error", input)
+		}
+	}
+}
+
+func TestCalculateLoRAServingMemory(t *testing.T) {
+	// A bf16 r=16 all-linear adapter for Llama-3-8B is an 84 MB safetensors file
+	adapter := LoRAParams{Rank: 16}
+
+	tests := []struct {
+		name   string
+		params LoRAServingParams
+		dtype  DataType
+		want   float64
+	}{
+		{"one bf16 adapter", LoRAServingParams{LoRA: adapter, MaxLoRAs: 1}, BFloat16, 0.08},
+		{"four bf16 adapters", LoRAServingParams{LoRA: adapter, MaxLoRAs: 4}, BFloat16, 0.34},
+		{"fp32 base weights keep fp32 adapters", LoRAServingParams{LoRA: adapter, MaxLoRAs: 4}, Float32, 0.67},
+		{"quantized base weights serve fp16 adapters", LoRAServingParams{LoRA: adapter, MaxLoRAs: 4}, Int4, 0.34},
+		{"slots sized at the maximum rank", LoRAServingParams{LoRA: LoRAParams{Rank: 64}, MaxLoRAs: 8}, "fp8", 2.68},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CalculateLoRAServingMemory(llama3Config(), tt.params, tt.dtype)
+			if err != nil {
+				t.Fatalf("CalculateLoRAServingMemory() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CalculateLoRAServingMemory() = %.2f GB, want %.2f GB", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := CalculateLoRAServingMemory(llama3Config(), LoRAServingParams{LoRA: adapter}, BFloat16); err == nil {
+		t.Error("expected an error for no resident adapters")
+	}
+}
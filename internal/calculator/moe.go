@@ -0,0 +1,117 @@
+// internal/calculator/moe.go
+
+package calculator
+
+// MoEInfo summarizes the expert layout of a Mixture-of-Experts model.
+// Parameter counts are in billions.
+type MoEInfo struct {
+	NumExperts      int
+	ExpertsPerToken int
+	SharedExperts   int
+	MoELayers       int
+
+	ExpertParamsB float64 // One routed expert summed over all MoE layers
+	RoutedParamsB float64 // All routed experts
+	SharedParamsB float64 // Shared experts, always active
+	TotalParamsB  float64
+	ActiveParamsB float64 // Parameters used per token
+}
+
+// numExperts returns the routed expert count under its various config names
+func (c *ModelConfig) numExperts() int {
+	switch {
+	case c.NumLocalExperts > 0:
+		return c.NumLocalExperts
+	case c.NRoutedExperts > 0:
+		return c.NRoutedExperts
+	default:
+		return c.NumExperts
+	}
+}
+
+// IsMoE reports whether the model routes tokens to experts
+func (c *ModelConfig) IsMoE() bool {
+	return c.numExperts() > 1 && c.NumExpertsPerTok > 0
+}
+
+// isMoELayer reports whether decoder layer i has routed experts. The first
+// first_k_dense_replace layers are dense; after them every layer is MoE, or
+// every decoder_sparse_step-th layer, or the layers at expert_layer_offset of
+// each expert_layer_period as in Jamba.
+func (c *ModelConfig) isMoELayer(i int) bool {
+	switch {
+	case i < c.FirstKDenseReplace:
+		return false
+	case c.ExpertLayerPeriod > 0:
+		return i%c.ExpertLayerPeriod == c.ExpertLayerOffset
+	case c.DecoderSparseStep > 1:
+		return (i+1)%c.DecoderSparseStep == 0
+	}
+	return true
+}
+
+// moeLayers returns the number of decoder layers with routed experts
+func (c *ModelConfig) moeLayers() int {
+	layers := 0
+	for i := 0; i < c.NumHiddenLayers; i++ {
+		if c.isMoELayer(i) {
+			layers++
+		}
+	}
+	return layers
+}
+
+// AnalyzeMoE splits the total parameter count into routed, shared and active
+// parameters. Experts are gated MLPs with gate, up and down projections.
+func (c *ModelConfig) AnalyzeMoE(totalParamsB float64) (*MoEInfo, bool) {
+	if !c.IsMoE() {
+		return nil, false
+	}
+
+	expertSize := c.MoEIntermediateSize
+	if expertSize == 0 {
+		expertSize = c.IntermediateSize
+	}
+	if expertSize == 0 || c.HiddenSize == 0 {
+		return nil, false
+	}
+
+	const gatedProjections = 3
+	layers := float64(c.moeLayers())
+	perExpert := float64(gatedProjections*c.HiddenSize*expertSize) * layers / 1e9
+
+	sharedExperts := c.NSharedExperts
+	shared := float64(sharedExperts) * perExpert
+	if c.SharedExpertIntermediateSize > 0 {
+		sharedExperts = 1
+		shared = float64(gatedProjections*c.HiddenSize*c.SharedExpertIntermediateSize) * layers / 1e9
+	}
+
+	numExperts := c.numExperts()
+	routed := float64(numExperts) * perExpert
+	inactive := float64(numExperts-c.NumExpertsPerTok) * perExpert
+
+	return &MoEInfo{
+		NumExperts:      numExperts,
+		ExpertsPerToken: c.NumExpertsPerTok,
+		SharedExperts:   sharedExperts,
+		MoELayers:       c.moeLayers(),
+		ExpertParamsB:   perExpert,
+		RoutedParamsB:   routed,
+		SharedParamsB:   shared,
+		TotalParamsB:    totalParamsB,
+		ActiveParamsB:   max(totalParamsB-inactive, 0),
+	}, true
+}
+
+// ExpertParallelFraction returns the fraction of the model's weights each GPU
+// holds when routed experts are spread over expertParallel GPUs and all other
+// weights (attention, embeddings, shared experts) are replicated
+func (i *MoEInfo) ExpertParallelFraction(expertParallel int) float64 {
+	if i.TotalParamsB == 0 || expertParallel < 1 {
+		return 1
+	}
+	replicated := max(i.TotalParamsB-i.RoutedParamsB, 0)
+	routedPerGPU := i.RoutedParamsB / float64(min(expertParallel, i.NumExperts))
+	return min((replicated+routedPerGPU)/i.TotalParamsB, 1)
+}
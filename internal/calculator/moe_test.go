@@ -0,0 +1,115 @@
+// internal/calculator/moe_test.go
+
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAnalyzeMoE(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     *ModelConfig
+		totalB     float64
+		wantActive float64 // Published active parameters in billions
+		wantLayers int
+		wantShared int
+	}{
+		{
+			name:       "mixtral 8x7b",
+			config:     mixtralConfig(),
+			totalB:     46.7,
+			wantActive: 12.9,
+			wantLayers: 32,
+		},
+		{
+			// Qwen/Qwen1.5-MoE-A2.7B
+			name: "qwen moe with a shared expert",
+			config: &ModelConfig{ModelType: "qwen2_moe", HiddenSize: 2048, NumHiddenLayers: 24,
+				NumExperts: 60, NumExpertsPerTok: 4, MoEIntermediateSize: 1408,
+				SharedExpertIntermediateSize: 5632},
+			totalB:     14.3,
+			wantActive: 2.7,
+			wantLayers: 24,
+			wantShared: 1,
+		},
+		{
+			// deepseek-ai/DeepSeek-V3
+			name: "deepseek v3 with dense leading layers",
+			config: &ModelConfig{ModelType: "deepseek_v3", HiddenSize: 7168, NumHiddenLayers: 61,
+				NRoutedExperts: 256, NumExpertsPerTok: 8, MoEIntermediateSize: 2048,
+				NSharedExperts: 1, FirstKDenseReplace: 3},
+			totalB:     671,
+			wantActive: 37,
+			wantLayers: 58,
+			wantShared: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, ok := tt.config.AnalyzeMoE(tt.totalB)
+			if !ok {
+				t.Fatal("AnalyzeMoE() reported a dense model")
+			}
+			if info.MoELayers != tt.wantLayers || info.SharedExperts != tt.wantShared {
+				t.Errorf("MoELayers, SharedExperts = %d, %d, want %d, %d",
+					info.MoELayers, info.SharedExperts, tt.wantLayers, tt.wantShared)
+			}
+			if diff := math.Abs(info.ActiveParamsB-tt.wantActive) / tt.wantActive; diff > 0.03 {
+				t.Errorf("ActiveParamsB = %.2fB, want %.1fB", info.ActiveParamsB, tt.wantActive)
+			}
+		})
+	}
+
+	if _, ok := llama3Config().AnalyzeMoE(8.03); ok {
+		t.Error("AnalyzeMoE() reported experts for a dense model")
+	}
+}
+
+func TestExpertParallelFraction(t *testing.T) {
+	info, _ := mixtralConfig().AnalyzeMoE(46.7)
+	replicated := info.TotalParamsB - info.RoutedParamsB
+
+	tests := []struct {
+		expertParallel int
+		want           float64
+	}{
+		{1, 1},
+		{2, (replicated + info.RoutedParamsB/2) / info.TotalParamsB},
+		{8, (replicated + info.ExpertParamsB) / info.TotalParamsB},
+		{16, (replicated + info.ExpertParamsB) / info.TotalParamsB}, // More GPUs than experts
+	}
+
+	for _, tt := range tests {
+		if got := info.ExpertParallelFraction(tt.expertParallel); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("ExpertParallelFraction(%d) = %.4f, want %.4f", tt.expertParallel, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeMoEExpertLayerPeriod(t *testing.T) {
+	config := jambaConfig()
+
+	info, ok := config.AnalyzeMoE(51.57)
+	if !ok {
+		t.Fatal("AnalyzeMoE: Jamba not recognized as MoE")
+	}
+	if info.MoELayers != 16 {
+		t.Errorf("MoELayers = %d, want 16", info.MoELayers)
+	}
+	// Two of sixteen experts in every other layer leave about 12B active
+	if math.Abs(info.ActiveParamsB-12.1) > 0.1 {
+		t.Errorf("ActiveParamsB = %.2f, want about 12.1", info.ActiveParamsB)
+	}
+	if math.Abs(info.RoutedParamsB-45.1) > 0.1 {
+		t.Errorf("RoutedParamsB = %.2f, want about 45.1", info.RoutedParamsB)
+	}
+
+	for i, want := range []bool{false, true, false, true} {
+		if got := config.isMoELayer(i); got != want {
+			t.Errorf("isMoELayer(%d) = %v, want %v", i, got, want)
+		}
+	}
+}
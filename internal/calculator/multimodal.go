@@ -0,0 +1,353 @@
+// internal/calculator/multimodal.go
+
+package calculator
+
+import (
+	"fmt"
+	"math"
+)
+
+// VisionConfig represents the vision encoder fields of a multimodal config.json
+type VisionConfig struct {
+	ModelType        string  `json:"model_type"`
+	HiddenSize       int     `json:"hidden_size"`
+	EmbedDim         int     `json:"embed_dim"` // Qwen2-VL encoder width, its hidden_size is the decoder's
+	NumHiddenLayers  int     `json:"num_hidden_layers"`
+	Depth            int     `json:"depth"`             // Qwen2-VL and Qwen2.5-VL layer count
+	NumGlobalLayers  int     `json:"num_global_layers"` // Llama-3.2-Vision global encoder layers
+	IntermediateSize int     `json:"intermediate_size"`
+	MLPRatio         float64 `json:"mlp_ratio"`
+	ImageSize        int     `json:"image_size"`
+	PatchSize        int     `json:"patch_size"`
+	SpatialMergeSize int     `json:"spatial_merge_size"` // Qwen-VL merges patches into tokens
+	MaxNumTiles      int     `json:"max_num_tiles"`      // Llama-3.2-Vision tiles per image
+}
+
+// PerceiverConfig represents the perceiver resampler of Idefics2
+type PerceiverConfig struct {
+	ResamplerNLatents int `json:"resampler_n_latents"`
+}
+
+// decoderDefaults holds the transformers defaults of decoder families whose
+// nested text configs omit fields left at their default
+var decoderDefaults = map[string]ModelConfig{
+	"llama": {
+		HiddenSize: 4096, IntermediateSize: 11008, NumHiddenLayers: 32,
+		NumAttentionHeads: 32, VocabSize: 32000, MaxPositionEmbeddings: 2048,
+	},
+	"mistral": {
+		HiddenSize: 4096, IntermediateSize: 14336, NumHiddenLayers: 32,
+		NumAttentionHeads: 32, NumKeyValueHeads: 8, VocabSize: 32000, MaxPositionEmbeddings: 131072,
+	},
+	"gemma3_text": {
+		HiddenSize: 2304, IntermediateSize: 9216, NumHiddenLayers: 26,
+		NumAttentionHeads: 8, NumKeyValueHeads: 4, HeadDim: 256, VocabSize: 262208,
+		MaxPositionEmbeddings: 131072, SlidingWindow: 4096,
+	},
+}
+
+// imageTilingFamilies lists multimodal families that split large images into
+// tiles of the encoder's image size, with the most tiles per image. A
+// downscaled thumbnail of the whole image is encoded as well.
+var imageTilingFamilies = map[string]int{
+	"llava_next":    4,
+	"idefics3":      16,
+	"internvl_chat": 12,
+}
+
+// Qwen-VL's default limit on the pixels of a resized image
+const qwenVLMaxPixels = 16384 * 28 * 28
+
+// liftTextConfig moves the decoder of a multimodal config, nested under
+// text_config or llm_config, to the top level and reports whether it did.
+// Configs that already have top-level decoder dimensions, as Qwen2-VL, are kept.
+func (c *ModelConfig) liftTextConfig() bool {
+	text := c.TextConfig
+	if text == nil {
+		text = c.LLMConfig
+	}
+	if text == nil || c.HiddenSize > 0 {
+		return false
+	}
+	text.applyDecoderDefaults()
+
+	lifted := *text
+	lifted.MultimodalType = c.ModelType
+	if len(c.Architectures) > 0 {
+		lifted.Architectures = c.Architectures
+	}
+	if lifted.TieWordEmbeddings == nil {
+		lifted.TieWordEmbeddings = c.TieWordEmbeddings // Llava sets it on the outer config
+	}
+	if lifted.TorchDtype == "" {
+		lifted.TorchDtype = c.TorchDtype
+	}
+	if lifted.QuantizationConfig == nil {
+		lifted.QuantizationConfig = c.QuantizationConfig
+	}
+	lifted.VisionConfig = c.VisionConfig
+	lifted.PerceiverConfig = c.PerceiverConfig
+	lifted.MMTokensPerImage = c.MMTokensPerImage
+	lifted.ImageSeqLength = c.ImageSeqLength
+	lifted.ScaleFactor = c.ScaleFactor
+	lifted.DownsampleRatio = c.DownsampleRatio
+	lifted.MaxDynamicPatch = c.MaxDynamicPatch
+	lifted.TextConfig, lifted.LLMConfig = nil, nil
+	*c = lifted
+	return true
+}
+
+// applyDecoderDefaults fills the fields a nested text config left at the
+// defaults of its family
+func (c *ModelConfig) applyDecoderDefaults() {
+	defaults, ok := decoderDefaults[c.family()]
+	if !ok {
+		return
+	}
+	for _, field := range []struct{ value, fallback *int }{
+		{&c.HiddenSize, &defaults.HiddenSize},
+		{&c.IntermediateSize, &defaults.IntermediateSize},
+		{&c.NumHiddenLayers, &defaults.NumHiddenLayers},
+		{&c.NumAttentionHeads, &defaults.NumAttentionHeads},
+		{&c.NumKeyValueHeads, &defaults.NumKeyValueHeads},
+		{&c.HeadDim, &defaults.HeadDim},
+		{&c.VocabSize, &defaults.VocabSize},
+		{&c.MaxPositionEmbeddings, &defaults.MaxPositionEmbeddings},
+		{&c.SlidingWindow, &defaults.SlidingWindow},
+	} {
+		if *field.value == 0 {
+			*field.value = *field.fallback
+		}
+	}
+}
+
+// multimodalFamily returns the model_type of the multimodal model
+func (c *ModelConfig) multimodalFamily() string {
+	if c.MultimodalType != "" {
+		return c.MultimodalType
+	}
+	return c.family()
+}
+
+// IsMultimodal reports whether the model has a vision encoder
+func (c *ModelConfig) IsMultimodal() bool {
+	return c.VisionConfig != nil
+}
+
+// hasCrossAttention reports whether decoder layers cross-attend to image tokens
+// instead of reading them from the context
+func (c *ModelConfig) hasCrossAttention() bool {
+	return len(c.CrossAttentionLayers) > 0
+}
+
+// VisionEncoderInfo summarizes the vision encoder of a multimodal model
+type VisionEncoderInfo struct {
+	Layers     int
+	HiddenSize int
+	ParamsB    float64 // Encoder and projector parameters in billions
+}
+
+// width returns the hidden size of the encoder layers
+func (v *VisionConfig) width() int {
+	if v.EmbedDim > 0 {
+		return v.EmbedDim
+	}
+	return v.HiddenSize
+}
+
+// layers returns the number of encoder layers
+func (v *VisionConfig) layers() int {
+	if v.Depth > 0 {
+		return v.Depth + v.NumGlobalLayers
+	}
+	return v.NumHiddenLayers + v.NumGlobalLayers
+}
+
+// intermediateSize returns the encoder MLP width, four times the hidden size
+// unless configured
+func (v *VisionConfig) intermediateSize() int {
+	switch {
+	case v.IntermediateSize > 0:
+		return v.IntermediateSize
+	case v.MLPRatio > 0:
+		return int(v.MLPRatio * float64(v.width()))
+	default:
+		return 4 * v.width()
+	}
+}
+
+// VisionEncoder estimates the parameters of the vision encoder and the
+// projector into the decoder. Encoder layers are ViT blocks with four attention
+// projections and an up and down MLP projection; the projector is a two-layer
+// MLP from the merged patch features to the decoder's hidden size. The
+// parameters are part of the checkpoint and already counted in the weights.
+func (c *ModelConfig) VisionEncoder() (*VisionEncoderInfo, bool) {
+	v := c.VisionConfig
+	if v == nil || v.width() == 0 || v.layers() == 0 {
+		return nil, false
+	}
+
+	hidden := float64(v.width())
+	perLayer := 4*hidden*hidden + 2*hidden*float64(v.intermediateSize())
+	encoder := float64(v.layers()) * perLayer
+	if v.PatchSize > 0 {
+		encoder += 3 * float64(v.PatchSize*v.PatchSize) * hidden
+		if v.ImageSize > 0 {
+			patches := v.ImageSize / v.PatchSize
+			encoder += float64(patches*patches) * hidden
+		}
+	}
+
+	merged := hidden
+	switch {
+	case v.SpatialMergeSize > 1:
+		merged *= float64(v.SpatialMergeSize * v.SpatialMergeSize)
+	case c.ScaleFactor > 1:
+		merged *= float64(c.ScaleFactor * c.ScaleFactor)
+	case c.DownsampleRatio > 0:
+		merged /= c.DownsampleRatio * c.DownsampleRatio
+	}
+	decoder := float64(c.HiddenSize)
+	projector := merged*decoder + decoder*decoder
+
+	return &VisionEncoderInfo{
+		Layers:     v.layers(),
+		HiddenSize: v.width(),
+		ParamsB:    (encoder + projector) / 1e9,
+	}, true
+}
+
+// ImageTokens returns the tokens one image of width × height pixels occupies
+// in the decoder, or 0 for models without a vision encoder:
+//   - Qwen-VL resizes the image to multiples of the merged patch size and
+//     merges spatial_merge_size² patches into each token
+//   - Gemma-3, PaliGemma and Idefics2 produce a fixed number of tokens
+//   - other encoders produce one token per patch of their image size, reduced
+//     by pixel shuffling (Idefics3, InternVL), for every tile of tiling models
+func (c *ModelConfig) ImageTokens(width, height int) int {
+	v := c.VisionConfig
+	if v == nil || width <= 0 || height <= 0 {
+		return 0
+	}
+	if v.SpatialMergeSize > 0 && v.PatchSize > 0 {
+		return v.dynamicResolutionTokens(width, height)
+	}
+	return c.tileTokens() * c.imageTiles(width, height)
+}
+
+// dynamicResolutionTokens returns the tokens of a Qwen-VL image
+func (v *VisionConfig) dynamicResolutionTokens(width, height int) int {
+	factor := float64(v.PatchSize * v.SpatialMergeSize)
+	h := max(factor, math.Round(float64(height)/factor)*factor)
+	w := max(factor, math.Round(float64(width)/factor)*factor)
+	if h*w > qwenVLMaxPixels {
+		scale := math.Sqrt(float64(height*width) / qwenVLMaxPixels)
+		h = max(factor, math.Floor(float64(height)/scale/factor)*factor)
+		w = max(factor, math.Floor(float64(width)/scale/factor)*factor)
+	}
+	patches := int(h/float64(v.PatchSize)) * int(w/float64(v.PatchSize))
+	return patches / (v.SpatialMergeSize * v.SpatialMergeSize)
+}
+
+// tileTokens returns the tokens of one encoded tile
+func (c *ModelConfig) tileTokens() int {
+	switch {
+	case c.MMTokensPerImage > 0:
+		return c.MMTokensPerImage
+	case c.ImageSeqLength > 0:
+		return c.ImageSeqLength
+	case c.PerceiverConfig != nil && c.PerceiverConfig.ResamplerNLatents > 0:
+		return c.PerceiverConfig.ResamplerNLatents
+	}
+
+	v := c.VisionConfig
+	if v.ImageSize == 0 || v.PatchSize == 0 {
+		return 0
+	}
+	side := v.ImageSize / v.PatchSize
+	tokens := side * side
+	switch {
+	case c.ScaleFactor > 1:
+		tokens /= c.ScaleFactor * c.ScaleFactor
+	case c.DownsampleRatio > 0:
+		tokens = int(math.Round(float64(tokens) * c.DownsampleRatio * c.DownsampleRatio))
+	}
+	if v.MaxNumTiles > 0 {
+		tokens++ // Llama-3.2-Vision keeps the class token of every tile
+	}
+	return tokens
+}
+
+// imageTiles returns the number of tiles an image is encoded as
+func (c *ModelConfig) imageTiles(width, height int) int {
+	v := c.VisionConfig
+	maxTiles := v.MaxNumTiles
+	if maxTiles == 0 {
+		maxTiles = c.MaxDynamicPatch
+	}
+	if maxTiles == 0 {
+		maxTiles = imageTilingFamilies[c.multimodalFamily()]
+	}
+	if maxTiles <= 1 || v.ImageSize == 0 {
+		return 1
+	}
+
+	across := (width + v.ImageSize - 1) / v.ImageSize
+	down := (height + v.ImageSize - 1) / v.ImageSize
+	tiles := min(across*down, maxTiles)
+	if tiles > 1 && v.MaxNumTiles == 0 {
+		tiles++ // Thumbnail of the whole image
+	}
+	return tiles
+}
+
+// ImageParams describes the images each user sends
+type ImageParams struct {
+	Images int // Images per user
+	Width  int // Pixels
+	Height int
+}
+
+// Validate checks the image count and resolution
+func (p ImageParams) Validate() error {
+	if p.Images < 0 {
+		return fmt.Errorf("number of images must not be negative")
+	}
+	if p.Images > 0 && (p.Width < 1 || p.Height < 1) {
+		return fmt.Errorf("image resolution must be at least 1x1, got %dx%d", p.Width, p.Height)
+	}
+	return nil
+}
+
+// ImageTokens returns the vision tokens of a user's images for a model
+func (p ImageParams) ImageTokens(config *ModelConfig) (int, error) {
+	if err := p.Validate(); err != nil {
+		return 0, err
+	}
+	if p.Images == 0 {
+		return 0, nil
+	}
+	if config == nil || !config.IsMultimodal() {
+		return 0, fmt.Errorf("model has no vision encoder")
+	}
+	tokens := config.ImageTokens(p.Width, p.Height)
+	if tokens == 0 {
+		return 0, fmt.Errorf("cannot determine the image tokens of %s", config.multimodalFamily())
+	}
+	return tokens * p.Images, nil
+}
+
+// CalculateImageKVCache returns the part of the KV cache holding the image
+// tokens of kv.ImageTokens, the difference to the cache of the text alone
+func CalculateImageKVCache(kv KVCacheParams) (float64, error) {
+	withImages, err := CalculateKVCache(kv)
+	if err != nil {
+		return 0, err
+	}
+	kv.ImageTokens = 0
+	textOnly, err := CalculateKVCache(kv)
+	if err != nil {
+		return 0, err
+	}
+	return round(withImages-textOnly, 2), nil
+}
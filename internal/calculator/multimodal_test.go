@@ -0,0 +1,228 @@
+// internal/calculator/multimodal_test.go
+
+package calculator
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// parseMultimodalConfig decodes a config.json and lifts its text config
+func parseMultimodalConfig(t *testing.T, data string) *ModelConfig {
+	t.Helper()
+	var config ModelConfig
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	config.liftTextConfig()
+	return &config
+}
+
+// llava-hf/llava-1.5-7b-hf
+const llavaConfig = `{
+	"model_type": "llava",
+	"architectures": ["LlavaForConditionalGeneration"],
+	"text_config": {"model_type": "llama", "max_position_embeddings": 4096, "vocab_size": 32064},
+	"vision_config": {"model_type": "clip_vision_model", "hidden_size": 1024, "intermediate_size": 4096,
+		"num_hidden_layers": 24, "image_size": 336, "patch_size": 14},
+	"torch_dtype": "float16"
+}`
+
+func TestLiftTextConfig(t *testing.T) {
+	config := parseMultimodalConfig(t, llavaConfig)
+
+	// Fields the text config omits take the transformers defaults of Llama
+	if config.HiddenSize != 4096 || config.NumHiddenLayers != 32 || config.IntermediateSize != 11008 {
+		t.Errorf("decoder = %d hidden, %d layers, %d intermediate, want the Llama-2-7B defaults",
+			config.HiddenSize, config.NumHiddenLayers, config.IntermediateSize)
+	}
+	if config.VocabSize != 32064 || config.MaxPositionEmbeddings != 4096 {
+		t.Errorf("vocab, positions = %d, %d, want the text config's 32064, 4096",
+			config.VocabSize, config.MaxPositionEmbeddings)
+	}
+	if config.multimodalFamily() != "llava" || config.family() != "llama" {
+		t.Errorf("families = %s, %s, want llava around llama", config.multimodalFamily(), config.family())
+	}
+	if config.TorchDtype != "float16" || !config.IsMultimodal() || config.TextConfig != nil {
+		t.Errorf("lifted config = %+v, want the outer torch_dtype and vision config", config)
+	}
+
+	// The text config's own tie_word_embeddings wins over the outer config's,
+	// and Llama's untied default applies when neither sets it
+	if config.tiedEmbeddings() {
+		t.Error("tiedEmbeddings() = true, want Llama's untied default")
+	}
+	for _, tt := range []struct {
+		outer, text string
+		want        bool
+	}{
+		{`"tie_word_embeddings": true,`, ``, true},
+		{`"tie_word_embeddings": true,`, `"tie_word_embeddings": false,`, false},
+	} {
+		lifted := parseMultimodalConfig(t, `{"model_type": "llava", `+tt.outer+`
+			"text_config": {`+tt.text+` "model_type": "llama"}}`)
+		if got := lifted.tiedEmbeddings(); got != tt.want {
+			t.Errorf("outer %q, text %q: tiedEmbeddings() = %v, want %v", tt.outer, tt.text, got, tt.want)
+		}
+	}
+
+	// Configs with top-level decoder dimensions are kept
+	qwen := parseMultimodalConfig(t, `{"model_type": "qwen2_vl", "hidden_size": 3584, "num_hidden_layers": 28,
+		"text_config": {"hidden_size": 1}, "vision_config": {"embed_dim": 1280, "depth": 32}}`)
+	if qwen.HiddenSize != 3584 {
+		t.Errorf("HiddenSize = %d, want the top-level 3584", qwen.HiddenSize)
+	}
+}
+
+func TestImageTokens(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        string
+		width, height int
+		want          int
+	}{
+		{"llava 1.5 at 336px", llavaConfig, 336, 336, 576},
+		{"llava 1.5 resizes large images", llavaConfig, 1920, 1080, 576},
+		{
+			// Four tiles and a thumbnail, llava-hf/llava-v1.6-mistral-7b-hf
+			name: "llava next tiles",
+			config: `{"model_type": "llava_next", "text_config": {"model_type": "mistral"},
+				"vision_config": {"hidden_size": 1024, "num_hidden_layers": 24, "image_size": 336, "patch_size": 14}}`,
+			width: 672, height: 672,
+			want: 2880,
+		},
+		{
+			// Four tiles of 1601 tokens, meta-llama/Llama-3.2-11B-Vision
+			name: "llama 3.2 vision",
+			config: `{"model_type": "mllama", "text_config": {"model_type": "mllama_text_model", "hidden_size": 4096,
+				"num_hidden_layers": 40, "num_attention_heads": 32, "num_key_value_heads": 8},
+				"vision_config": {"hidden_size": 1280, "num_hidden_layers": 32, "num_global_layers": 8,
+				"image_size": 560, "patch_size": 14, "max_num_tiles": 4}}`,
+			width: 1120, height: 1120,
+			want: 6404,
+		},
+		{
+			// One token per 28x28 pixels, Qwen/Qwen2-VL-7B-Instruct
+			name: "qwen2-vl",
+			config: `{"model_type": "qwen2_vl", "hidden_size": 3584, "num_hidden_layers": 28,
+				"vision_config": {"embed_dim": 1280, "depth": 32, "patch_size": 14, "spatial_merge_size": 2}}`,
+			width: 448, height: 448,
+			want: 256,
+		},
+		{
+			name: "qwen2-vl caps the pixels",
+			config: `{"model_type": "qwen2_vl", "hidden_size": 3584, "num_hidden_layers": 28,
+				"vision_config": {"embed_dim": 1280, "depth": 32, "patch_size": 14, "spatial_merge_size": 2}}`,
+			width: 8000, height: 6000,
+			want: 110 * 147, // smart_resize to 3080x4116 within 16384 tokens
+		},
+		{
+			// google/gemma-3-4b-it
+			name: "gemma 3",
+			config: `{"model_type": "gemma3", "mm_tokens_per_image": 256, "text_config": {"model_type": "gemma3_text",
+				"hidden_size": 2560, "num_hidden_layers": 34}, "vision_config": {"hidden_size": 1152,
+				"num_hidden_layers": 27, "image_size": 896, "patch_size": 14}}`,
+			width: 1920, height: 1080,
+			want: 256,
+		},
+		{
+			// Two tiles and a thumbnail of 256 tokens, OpenGVLab/InternVL2-8B
+			name: "internvl",
+			config: `{"model_type": "internvl_chat", "downsample_ratio": 0.5, "max_dynamic_patch": 12,
+				"llm_config": {"model_type": "internlm2", "hidden_size": 4096, "num_hidden_layers": 32},
+				"vision_config": {"hidden_size": 1024, "num_hidden_layers": 24, "image_size": 448, "patch_size": 14}}`,
+			width: 896, height: 448,
+			want: 768,
+		},
+		{
+			name:   "text-only model",
+			config: `{"model_type": "llama", "hidden_size": 4096}`,
+			width:  448, height: 448,
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := parseMultimodalConfig(t, tt.config)
+			if got := config.ImageTokens(tt.width, tt.height); got != tt.want {
+				t.Errorf("ImageTokens(%d, %d) = %d, want %d", tt.width, tt.height, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageParamsImageTokens(t *testing.T) {
+	config := parseMultimodalConfig(t, llavaConfig)
+
+	got, err := ImageParams{Images: 3, Width: 336, Height: 336}.ImageTokens(config)
+	if err != nil || got != 3*576 {
+		t.Errorf("ImageTokens() = %d, %v, want %d", got, err, 3*576)
+	}
+	if got, err := (ImageParams{}).ImageTokens(nil); err != nil || got != 0 {
+		t.Errorf("ImageTokens() without images = %d, %v, want 0", got, err)
+	}
+
+	for _, p := range []ImageParams{{Images: -1}, {Images: 1}, {Images: 1, Width: 336, Height: 0}} {
+		if _, err := p.ImageTokens(config); err == nil {
+			t.Errorf("%+v: expected an error", p)
+		}
+	}
+	if _, err := (ImageParams{Images: 1, Width: 336, Height: 336}).ImageTokens(llama3Config()); err == nil {
+		t.Error("expected an error for a model without a vision encoder")
+	}
+}
+
+func TestVisionEncoder(t *testing.T) {
+	config := parseMultimodalConfig(t, llavaConfig)
+	info, ok := config.VisionEncoder()
+	if !ok {
+		t.Fatal("VisionEncoder() found no encoder")
+	}
+	// CLIP ViT-L/14-336 has 303.5M parameters, the two-layer projector 21M
+	if want := 0.3035 + 0.021; math.Abs(info.ParamsB-want)/want > 0.02 {
+		t.Errorf("ParamsB = %.4fB, want about %.4fB", info.ParamsB, want)
+	}
+	if info.Layers != 24 || info.HiddenSize != 1024 {
+		t.Errorf("encoder = %d layers of %d, want 24 of 1024", info.Layers, info.HiddenSize)
+	}
+}
+
+func TestCalculateImageKVCache(t *testing.T) {
+	const bf16 = 2.0
+
+	tests := []struct {
+		name   string
+		config *ModelConfig
+		want   float64 // Bytes of image tokens for all users
+	}{
+		{
+			// Image tokens are part of the context of every layer
+			name:   "image tokens in the context",
+			config: llama3Config(),
+			want:   2 * 6404 * 32 * 2 * 8 * 128 * bf16,
+		},
+		{
+			// Only the cross-attention layers cache the image tokens
+			name: "cross-attention to image tokens",
+			config: &ModelConfig{ModelType: "mllama_text_model", HiddenSize: 4096, NumHiddenLayers: 40,
+				NumAttentionHeads: 32, NumKeyValueHeads: 8,
+				CrossAttentionLayers: []int{3, 8, 13, 18, 23, 28, 33, 38}},
+			want: 2 * 6404 * 8 * 2 * 8 * 128 * bf16,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kv := KVCacheParams{Users: 2, ContextLength: 8192, ImageTokens: 6404, KVDataType: BFloat16, Config: tt.config}
+			got, err := CalculateImageKVCache(kv)
+			if err != nil {
+				t.Fatalf("CalculateImageKVCache() error = %v", err)
+			}
+			if want := round(tt.want/bytesPerGiB, 2); math.Abs(got-want) > 0.01 {
+				t.Errorf("CalculateImageKVCache() = %.2f GB, want %.2f GB", got, want)
+			}
+		})
+	}
+}
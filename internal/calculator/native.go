@@ -0,0 +1,73 @@
+// internal/calculator/native.go
+
+package calculator
+
+// Native selects the data types stored in the checkpoint instead of a uniform data type
+const Native DataType = "native"
+
+// safetensorsBytes maps safetensors dtype names to their element size in bytes.
+// Packed formats (GPTQ/AWQ int32, bitsandbytes uint8) are counted as stored.
+var safetensorsBytes = map[string]float64{
+	"F64":     8,
+	"F32":     4,
+	"F16":     2,
+	"BF16":    2,
+	"F8_E4M3": 1,
+	"F8_E5M2": 1,
+	"F8_E8M0": 1,
+	"I64":     8,
+	"I32":     4,
+	"I16":     2,
+	"I8":      1,
+	"U64":     8,
+	"U32":     4,
+	"U16":     2,
+	"U8":      1,
+	"BOOL":    1,
+}
+
+// HasPackedWeights reports whether the breakdown holds the int32 or uint8
+// tensors packed quantization formats store several weights in
+func HasPackedWeights(breakdown map[string]int64) bool {
+	return breakdown["I32"] > 0 || breakdown["U8"] > 0
+}
+
+// NativeWeightBytes computes the exact weight size from a safetensors
+// parameter breakdown (dtype name -> number of elements)
+func NativeWeightBytes(breakdown map[string]int64) (float64, error) {
+	if len(breakdown) == 0 {
+		return 0, ErrUnsupportedDataType{Native}
+	}
+
+	var total float64
+	for dtype, count := range breakdown {
+		bytes, ok := safetensorsBytes[dtype]
+		if !ok {
+			return 0, ErrUnsupportedDataType{DataType(dtype)}
+		}
+		total += float64(count) * bytes
+	}
+	return total, nil
+}
+
+// CalculateNativeWeightMemory calculates the GPU memory taken by the weights
+// as stored in the checkpoint
+func CalculateNativeWeightMemory(breakdown map[string]int64) (float64, error) {
+	bytes, err := NativeWeightBytes(breakdown)
+	if err != nil {
+		return 0, err
+	}
+
+	const bytesPerGB = 1e9
+	return round(bytes/bytesPerGB, 2), nil
+}
+
+// CalculateNativeGPUMemory calculates the GPU memory required for serving the
+// checkpoint as stored, applying the same overhead as CalculateGPUMemory
+func CalculateNativeGPUMemory(breakdown map[string]int64) (float64, error) {
+	weights, err := CalculateNativeWeightMemory(breakdown)
+	if err != nil {
+		return 0, err
+	}
+	return round(weights+DefaultOverhead().PerGPU(weights), 2), nil
+}
@@ -0,0 +1,88 @@
+// internal/calculator/native_test.go
+
+package calculator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNativeWeightBytes(t *testing.T) {
+	tests := []struct {
+		name      string
+		breakdown map[string]int64
+		want      float64
+	}{
+		// meta-llama/Meta-Llama-3-8B ships 8,030,261,248 BF16 parameters (16.06 GB)
+		{"llama3 8b bf16", map[string]int64{"BF16": 8030261248}, 16060522496},
+		{"mixed precision", map[string]int64{"F32": 1000, "BF16": 2000, "I8": 3000}, 4000 + 4000 + 3000},
+		{"packed int32", map[string]int64{"I32": 10, "F16": 10}, 40 + 20},
+		{"fp8 checkpoint", map[string]int64{"F8_E4M3": 7000, "BF16": 1000}, 7000 + 2000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NativeWeightBytes(tt.breakdown)
+			if err != nil {
+				t.Fatalf("NativeWeightBytes() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("NativeWeightBytes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNativeWeightBytesErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		breakdown map[string]int64
+	}{
+		{"empty breakdown", nil},
+		{"unknown dtype", map[string]int64{"BF16": 10, "C64": 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NativeWeightBytes(tt.breakdown)
+			var unsupported ErrUnsupportedDataType
+			if !errors.As(err, &unsupported) {
+				t.Errorf("NativeWeightBytes() error = %v, want ErrUnsupportedDataType", err)
+			}
+		})
+	}
+}
+
+func TestCalculateNativeGPUMemory(t *testing.T) {
+	got, err := CalculateNativeGPUMemory(map[string]int64{"BF16": 8030261248})
+	if err != nil {
+		t.Fatalf("CalculateNativeGPUMemory() error = %v", err)
+	}
+	// 16.06 GB of weights plus 0.75 GB fixed and 5% proportional overhead
+	if want := 17.61; got != want {
+		t.Errorf("CalculateNativeGPUMemory() = %v, want %v", got, want)
+	}
+
+	bf16, _ := CalculateGPUMemory(8.030261248, BFloat16)
+	if got != bf16 {
+		t.Errorf("native bf16 = %v, uniform bf16 = %v", got, bf16)
+	}
+}
+
+func TestHasPackedWeights(t *testing.T) {
+	tests := []struct {
+		breakdown map[string]int64
+		want      bool
+	}{
+		{map[string]int64{"BF16": 8030261248}, false},
+		{map[string]int64{"I32": 809500672, "F16": 313000000}, true},
+		{map[string]int64{"U8": 3500000000, "BF16": 1050000000, "F32": 1000}, true},
+		{map[string]int64{"F8_E4M3": 7000, "BF16": 1000}, false},
+	}
+
+	for _, tt := range tests {
+		if got := HasPackedWeights(tt.breakdown); got != tt.want {
+			t.Errorf("HasPackedWeights(%v) = %v, want %v", tt.breakdown, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// internal/calculator/overhead.go
+
+package calculator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// OverheadModel describes the GPU memory used beyond the weights and KV cache
+type OverheadModel struct {
+	FixedGB         float64 `json:"fixed_gb"`          // CUDA context and cuBLAS workspace on every GPU
+	Proportional    float64 `json:"proportional"`      // Fraction of the weight memory, e.g. allocator fragmentation
+	EngineReserveGB float64 `json:"engine_reserve_gb"` // Memory the serving engine reserves on every GPU
+}
+
+// defaultOverhead is used unless overridden by a config file or flags
+var defaultOverhead = OverheadModel{
+	FixedGB:      0.75,
+	Proportional: 0.05,
+}
+
+// DefaultOverhead returns the overhead model used by CalculateGPUMemory
+func DefaultOverhead() OverheadModel {
+	return defaultOverhead
+}
+
+// Validate checks that the overhead components are usable
+func (o OverheadModel) Validate() error {
+	if o.FixedGB < 0 || o.EngineReserveGB < 0 {
+		return fmt.Errorf("fixed overhead and engine reservation must not be negative")
+	}
+	if o.Proportional < 0 || o.Proportional >= 1 {
+		return fmt.Errorf("proportional overhead must be between 0 and 1, got %g", o.Proportional)
+	}
+	return nil
+}
+
+// PerGPU returns the overhead on a GPU holding weightsGB of weights
+func (o OverheadModel) PerGPU(weightsGB float64) float64 {
+	return round(o.FixedGB+o.EngineReserveGB+weightsGB*o.Proportional, 2)
+}
+
+// String describes the overhead components
+func (o OverheadModel) String() string {
+	s := fmt.Sprintf("%.2f GB fixed + %g%% of weights", o.FixedGB, o.Proportional*100)
+	if o.EngineReserveGB > 0 {
+		s += fmt.Sprintf(" + %.2f GB engine reserve", o.EngineReserveGB)
+	}
+	return s
+}
+
+// DefaultOverheadConfigPath returns the location of the user's overhead config
+func DefaultOverheadConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "huggyfit", "overhead.json")
+}
+
+// LoadOverheadConfig replaces the default overhead model with the one in a JSON
+// file. Fields missing from the file keep their current value.
+func LoadOverheadConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read overhead config: %w", err)
+	}
+
+	overhead := defaultOverhead
+	if err := json.Unmarshal(data, &overhead); err != nil {
+		return fmt.Errorf("failed to parse overhead config %s: %w", path, err)
+	}
+	if err := overhead.Validate(); err != nil {
+		return fmt.Errorf("invalid overhead config %s: %w", path, err)
+	}
+
+	defaultOverhead = overhead
+	return nil
+}
+
+// LoadDefaultOverheadConfig loads the user's overhead config if one exists
+func LoadDefaultOverheadConfig() error {
+	path := DefaultOverheadConfigPath()
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return LoadOverheadConfig(path)
+}
@@ -0,0 +1,104 @@
+// internal/calculator/overhead_test.go
+
+package calculator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOverheadModelPerGPU(t *testing.T) {
+	tests := []struct {
+		name      string
+		overhead  OverheadModel
+		weightsGB float64
+		want      float64
+	}{
+		// Llama-3-8B in bfloat16 holds 16.06 GB of weights
+		{"default", DefaultOverhead(), 16.06, 1.55},
+		{"default on an empty GPU", DefaultOverhead(), 0, 0.75},
+		{"engine reserve", OverheadModel{FixedGB: 0.5, Proportional: 0.1, EngineReserveGB: 2}, 10, 3.5},
+		{"none", OverheadModel{}, 140, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.overhead.PerGPU(tt.weightsGB); got != tt.want {
+				t.Errorf("PerGPU(%.2f) = %.2f, want %.2f", tt.weightsGB, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateGPUMemory(t *testing.T) {
+	tests := []struct {
+		parametersB float64
+		dtype       DataType
+		weights     float64
+		total       float64
+	}{
+		{8.03, BFloat16, 16.06, 17.61},
+		{8.03, Int4, 4.01, 4.96},
+		{70.6, FP8, 70.6, 74.88},
+	}
+
+	for _, tt := range tests {
+		weights, err := CalculateWeightMemory(tt.parametersB, tt.dtype)
+		if err != nil || weights != tt.weights {
+			t.Errorf("CalculateWeightMemory(%.2f, %s) = %.2f, %v, want %.2f", tt.parametersB, tt.dtype, weights, err, tt.weights)
+		}
+		total, err := CalculateGPUMemory(tt.parametersB, tt.dtype)
+		if err != nil || total != tt.total {
+			t.Errorf("CalculateGPUMemory(%.2f, %s) = %.2f, %v, want %.2f", tt.parametersB, tt.dtype, total, err, tt.total)
+		}
+	}
+}
+
+func TestOverheadModelValidate(t *testing.T) {
+	tests := []struct {
+		overhead OverheadModel
+		valid    bool
+	}{
+		{DefaultOverhead(), true},
+		{OverheadModel{}, true},
+		{OverheadModel{FixedGB: -1}, false},
+		{OverheadModel{EngineReserveGB: -0.5}, false},
+		{OverheadModel{Proportional: 1}, false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.overhead.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%+v.Validate() = %v, want valid %v", tt.overhead, err, tt.valid)
+		}
+	}
+}
+
+func TestLoadOverheadConfig(t *testing.T) {
+	saved := defaultOverhead
+	t.Cleanup(func() { defaultOverhead = saved })
+
+	path := filepath.Join(t.TempDir(), "overhead.json")
+	if err := os.WriteFile(path, []byte(`{"engine_reserve_gb": 1.5}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadOverheadConfig(path); err != nil {
+		t.Fatalf("LoadOverheadConfig() error = %v", err)
+	}
+
+	// Fields missing from the file keep the built-in defaults
+	want := OverheadModel{FixedGB: saved.FixedGB, Proportional: saved.Proportional, EngineReserveGB: 1.5}
+	if got := DefaultOverhead(); got != want {
+		t.Errorf("DefaultOverhead() = %+v, want %+v", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"proportional": 2}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadOverheadConfig(path); err == nil {
+		t.Error("expected an error for a proportional overhead above 1")
+	}
+	if got := DefaultOverhead(); got != want {
+		t.Errorf("an invalid config replaced the overhead model with %+v", got)
+	}
+}
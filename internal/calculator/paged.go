@@ -0,0 +1,206 @@
+// internal/calculator/paged.go
+
+package calculator
+
+import (
+	"fmt"
+	"math"
+)
+
+// PagedKVCacheParams holds vLLM's PagedAttention settings
+type PagedKVCacheParams struct {
+	BlockSize            int     // Tokens per KV cache block (block_size)
+	GPUMemoryUtilization float64 // Share of the GPU memory vLLM may use (gpu_memory_utilization)
+	MaxNumSeqs           int     // Sequences scheduled at once (max_num_seqs), 0 when unlimited
+	MaxNumBatchedTokens  int     // Tokens per forward pass (max_num_batched_tokens)
+}
+
+// DefaultPagedKVCacheParams returns vLLM's default settings
+func DefaultPagedKVCacheParams() PagedKVCacheParams {
+	return PagedKVCacheParams{
+		BlockSize:            16,
+		GPUMemoryUtilization: 0.9,
+		MaxNumSeqs:           256,
+		MaxNumBatchedTokens:  2048,
+	}
+}
+
+// Validate checks that the settings are usable
+func (p PagedKVCacheParams) Validate() error {
+	if p.BlockSize < 1 || p.MaxNumBatchedTokens < 1 {
+		return fmt.Errorf("block_size and max_num_batched_tokens must be at least 1")
+	}
+	if p.MaxNumSeqs < 0 {
+		return fmt.Errorf("max_num_seqs must not be negative")
+	}
+	if p.GPUMemoryUtilization <= 0 || p.GPUMemoryUtilization > 1 {
+		return fmt.Errorf("gpu_memory_utilization must be in (0, 1], got %g", p.GPUMemoryUtilization)
+	}
+	return nil
+}
+
+// PagedKVCache is the block layout vLLM reports at startup
+type PagedKVCache struct {
+	BlockSize      int
+	NumBlocks      int     // "# GPU blocks"
+	CapacityTokens int     // "GPU KV cache size" in tokens
+	MaxConcurrency float64 // "Maximum concurrency" for the context length
+	StateGB        float64 // Recurrent state reserved for max_num_seqs sequences on the busiest GPU
+}
+
+// CalculatePagedKVCache divides the KV cache memory available on the GPUs of each
+// pipeline stage into blocks. The stage that fits the fewest blocks limits the
+// cache, as vLLM uses the minimum over its workers.
+//
+// The recurrent state of state-space layers is a fixed amount per sequence, so
+// the state of max_num_seqs sequences (or of the users when unlimited) is
+// reserved first and only attention layers are paged. Models without attention
+// layers have no blocks; they run max_num_seqs sequences of any length.
+func CalculatePagedKVCache(parametersB float64, kv KVCacheParams, parallel ParallelConfig,
+	paged PagedKVCacheParams, availableGB []float64) (PagedKVCache, error) {
+	if err := paged.Validate(); err != nil {
+		return PagedKVCache{}, err
+	}
+	blockBytes, stateBytes, err := kvBlockBytes(parametersB, kv, parallel, paged.BlockSize)
+	if err != nil {
+		return PagedKVCache{}, err
+	}
+	if len(availableGB) != len(blockBytes) {
+		return PagedKVCache{}, fmt.Errorf("expected available memory for %d pipeline stages, got %d",
+			len(blockBytes), len(availableGB))
+	}
+
+	sequences := paged.MaxNumSeqs
+	if sequences == 0 {
+		sequences = max(kv.Users, 1)
+	}
+
+	numBlocks := math.MaxInt
+	var stateGB float64
+	for stage, bytes := range blockBytes {
+		state := float64(sequences) * stateBytes[stage] / bytesPerGiB
+		if state > max(availableGB[stage], 0) {
+			return PagedKVCache{}, fmt.Errorf("the recurrent state of %d sequences needs %.2f GB per GPU, only %.2f GB is available",
+				sequences, state, max(availableGB[stage], 0))
+		}
+		stateGB = max(stateGB, state)
+		if bytes == 0 {
+			continue // No attention layers on this stage
+		}
+		// The tolerance keeps exact fits from losing a block to floating point error
+		blocks := int(math.Floor(max(availableGB[stage]-state, 0)*bytesPerGiB/bytes + 1e-9))
+		numBlocks = min(numBlocks, blocks)
+	}
+
+	if numBlocks == math.MaxInt {
+		cache := newPagedKVCache(0, paged.BlockSize, kv.pagedTokens())
+		cache.MaxConcurrency = float64(sequences)
+		cache.StateGB = round(stateGB, 2)
+		return cache, nil
+	}
+	cache := newPagedKVCache(numBlocks, paged.BlockSize, kv.pagedTokens())
+	cache.StateGB = round(stateGB, 2)
+	return cache, nil
+}
+
+// newPagedKVCache describes a cache of numBlocks blocks for requests that keep
+// requestTokens tokens in blocks
+func newPagedKVCache(numBlocks, blockSize, requestTokens int) PagedKVCache {
+	cache := PagedKVCache{
+		BlockSize:      blockSize,
+		NumBlocks:      numBlocks,
+		CapacityTokens: numBlocks * blockSize,
+	}
+	if requestTokens > 0 {
+		cache.MaxConcurrency = float64(cache.CapacityTokens) / float64(requestTokens)
+	}
+	return cache
+}
+
+// blocksPerSequence returns the blocks one sequence of the context length occupies
+func (p PagedKVCacheParams) blocksPerSequence(contextLength int) int {
+	return (contextLength + p.BlockSize - 1) / p.BlockSize
+}
+
+// blocksPerRequest returns the blocks one request occupies: its context, plus
+// for encoder-decoder models the encoder output in a cross-attention block table
+func (p PagedKVCacheParams) blocksPerRequest(kv KVCacheParams) int {
+	if kv.Config == nil || !kv.Config.IsEncoderDecoder {
+		return p.blocksPerSequence(kv.ContextLength)
+	}
+	encoder, decoder := kv.EncoderDecoderLengths()
+	return p.blocksPerSequence(decoder) + p.blocksPerSequence(encoder)
+}
+
+// pagedTokens returns the tokens one request keeps in blocks: the context, or
+// the decoder output and the encoder input of encoder-decoder models
+func (p KVCacheParams) pagedTokens() int {
+	if p.Config == nil || !p.Config.IsEncoderDecoder {
+		return p.ContextLength
+	}
+	encoder, decoder := p.EncoderDecoderLengths()
+	return encoder + decoder
+}
+
+// kvBlockBytes returns the bytes of one KV cache block on a GPU of each pipeline
+// stage, and the bytes of one sequence's recurrent state there. Every attention
+// layer stores a full block, as in vLLM's single KV cache group; stages without
+// attention layers have blocks of 0 bytes. The decoder layers of encoder-decoder
+// models keep their cross-attention keys and values in blocks of the same size,
+// which blocksPerRequest counts.
+func kvBlockBytes(parametersB float64, kv KVCacheParams, parallel ParallelConfig, blockSize int) (blocks, states []float64, err error) {
+	tp := max(parallel.TensorParallel, 1)
+	pp := max(parallel.PipelineParallel, 1)
+	config := kv.Config
+
+	kvDataType := kv.kvDataType()
+	blocks = make([]float64, pp)
+	states = make([]float64, pp)
+	if config == nil || config.NumHiddenLayers == 0 {
+		if kv.Strict {
+			return nil, nil, ErrEstimateRefused{"no model config"}
+		}
+		// Without the architecture, split the estimate evenly
+		perToken := estimatedKVCachePerToken(parametersB, kvDataType) * bytesPerGiB / float64(tp*pp)
+		for stage := range blocks {
+			blocks[stage] = perToken * float64(blockSize)
+		}
+		return blocks, states, nil
+	}
+	if err := config.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if pp > config.NumHiddenLayers {
+		return nil, nil, fmt.Errorf("pipeline parallel size %d exceeds the %d layers of the model",
+			pp, config.NumHiddenLayers)
+	}
+
+	bytes, ok := BytesPerParameter(kvDataType)
+	if !ok || !ValidateKVDataType(kvDataType) {
+		return nil, nil, ErrUnsupportedDataType{kvDataType}
+	}
+
+	kvHeads := config.kvHeadsPerRank(tp)
+	kinds := config.layerKinds()
+	for stage, r := range pipelineStages(config.NumHiddenLayers, pp) {
+		var elements float64
+		for _, kind := range kinds[r.first:r.last] {
+			if kind == attentionCross || kind == attentionSSM {
+				continue // Image tokens and recurrent states are not kept in context blocks
+			}
+			elements += config.kvElementsPerToken(kind, kvHeads)
+		}
+		blocks[stage] = elements * bytes * float64(blockSize)
+		states[stage] = config.stateElementsPerRank(r.first, r.last, tp) * kv.stateBytes()
+	}
+	return blocks, states, nil
+}
+
+// samplerLogitsGB returns the fp32 logits vLLM's profiling run samples for
+// max_num_seqs sequences
+func samplerLogitsGB(config *ModelConfig, sequences int) float64 {
+	if config == nil {
+		return 0
+	}
+	return float64(sequences) * float64(config.VocabSize) * bytesPerFloat32 / bytesPerGiB
+}
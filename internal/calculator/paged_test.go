@@ -0,0 +1,166 @@
+// internal/calculator/paged_test.go
+
+package calculator
+
+import "testing"
+
+func TestCalculatePagedKVCache(t *testing.T) {
+	kv := KVCacheParams{Users: 1, ContextLength: 8192, KVDataType: BFloat16, Config: llama3Config()}
+
+	// A block of 16 tokens over 32 layers of 8 KV heads is 2 MiB
+	tests := []struct {
+		name        string
+		parallel    ParallelConfig
+		availableGB []float64
+		blockSize   int
+		wantBlocks  int
+	}{
+		{"single GPU", ParallelConfig{TensorParallel: 1, PipelineParallel: 1}, []float64{10}, 16, 5120},
+		{"larger blocks", ParallelConfig{TensorParallel: 1, PipelineParallel: 1}, []float64{10}, 32, 2560},
+		{"tensor parallel halves the block", ParallelConfig{TensorParallel: 2, PipelineParallel: 1}, []float64{10}, 16, 10240},
+		{"fewest blocks of the stages", ParallelConfig{TensorParallel: 1, PipelineParallel: 2}, []float64{10, 6}, 16, 6144},
+		{"no memory left", ParallelConfig{TensorParallel: 1, PipelineParallel: 1}, []float64{-1}, 16, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paged := DefaultPagedKVCacheParams()
+			paged.BlockSize = tt.blockSize
+			cache, err := CalculatePagedKVCache(8.03, kv, tt.parallel, paged, tt.availableGB)
+			if err != nil {
+				t.Fatalf("CalculatePagedKVCache: %v", err)
+			}
+			if cache.NumBlocks != tt.wantBlocks {
+				t.Errorf("NumBlocks = %d, want %d", cache.NumBlocks, tt.wantBlocks)
+			}
+			if want := tt.wantBlocks * tt.blockSize; cache.CapacityTokens != want {
+				t.Errorf("CapacityTokens = %d, want %d", cache.CapacityTokens, want)
+			}
+			if want := float64(cache.CapacityTokens) / 8192; cache.MaxConcurrency != want {
+				t.Errorf("MaxConcurrency = %.2f, want %.2f", cache.MaxConcurrency, want)
+			}
+		})
+	}
+
+	if _, err := CalculatePagedKVCache(8.03, kv, ParallelConfig{TensorParallel: 1, PipelineParallel: 2},
+		DefaultPagedKVCacheParams(), []float64{10}); err == nil {
+		t.Error("expected an error when the available memory doesn't match the stages")
+	}
+	if _, err := CalculatePagedKVCache(8.03, kv, ParallelConfig{}, PagedKVCacheParams{}, []float64{10}); err == nil {
+		t.Error("expected an error for a block size of 0")
+	}
+}
+
+func TestBlocksPerSequence(t *testing.T) {
+	paged := DefaultPagedKVCacheParams()
+	for _, tt := range []struct{ context, want int }{{1, 1}, {16, 1}, {17, 2}, {8192, 512}} {
+		if got := paged.blocksPerSequence(tt.context); got != tt.want {
+			t.Errorf("blocksPerSequence(%d) = %d, want %d", tt.context, got, tt.want)
+		}
+	}
+}
+
+func TestPagedKVCacheWithoutAttention(t *testing.T) {
+	config := mambaConfig()
+	kv := KVCacheParams{Users: 8, ContextLength: 4096, DataType: BFloat16, Config: config}
+	weights, err := CalculateWeightMemory(2.77, BFloat16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := NewVLLMEngine(DefaultPagedKVCacheParams())
+	estimate, err := engine.Estimate(EngineParams{
+		ParametersB: 2.77,
+		WeightsGB:   weights,
+		KV:          kv,
+		Parallel:    ParallelConfig{TensorParallel: 1, PipelineParallel: 1},
+		GPUMemoryGB: 24,
+	})
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if estimate.KVTokens < 0 || estimate.RequiredTokens != 0 {
+		t.Errorf("KVTokens = %d, RequiredTokens = %d, want no tokens in blocks",
+			estimate.KVTokens, estimate.RequiredTokens)
+	}
+	if !estimate.ServesWorkload() {
+		t.Errorf("ServesWorkload() = false for %d users of a Mamba model on 24 GB", kv.Users)
+	}
+
+	cache, err := CalculatePagedKVCache(2.77, kv, ParallelConfig{}, DefaultPagedKVCacheParams(), []float64{10})
+	if err != nil {
+		t.Fatalf("CalculatePagedKVCache: %v", err)
+	}
+	if cache.NumBlocks != 0 || cache.MaxConcurrency != 256 || cache.StateGB <= 0 {
+		t.Errorf("got %+v, want 0 blocks, concurrency of max_num_seqs and a reserved state", cache)
+	}
+}
+
+func TestPagedKVCacheStateOnlyStage(t *testing.T) {
+	// Jamba's first eight layers, of which the first four are all Mamba
+	config := jambaConfig()
+	config.NumHiddenLayers = 8
+	kv := KVCacheParams{Users: 1, ContextLength: 4096, DataType: BFloat16, Config: config}
+	paged := DefaultPagedKVCacheParams()
+
+	// The first stage holds only Mamba layers, the second the attention layer
+	cache, err := CalculatePagedKVCache(0, kv, ParallelConfig{TensorParallel: 1, PipelineParallel: 2},
+		paged, []float64{4, 4})
+	if err != nil {
+		t.Fatalf("CalculatePagedKVCache: %v", err)
+	}
+	if cache.NumBlocks <= 0 || cache.MaxConcurrency <= 0 {
+		t.Errorf("got %+v, want blocks sized by the attention stage", cache)
+	}
+
+	// The reserved state leaves fewer blocks than the attention layers alone
+	single, err := CalculatePagedKVCache(0, kv, ParallelConfig{}, paged, []float64{4})
+	if err != nil {
+		t.Fatalf("CalculatePagedKVCache: %v", err)
+	}
+	blockBytes := config.kvElementsPerToken(attentionFull, config.kvHeadsPerRank(1)) * 2 * 16
+	stateBytes := float64(paged.MaxNumSeqs) * 7 * config.ssmStateElements(1) * 2
+	if want := int((4*bytesPerGiB - stateBytes) / blockBytes); single.NumBlocks != want {
+		t.Errorf("NumBlocks = %d, want %d after reserving %.2f GB of state", single.NumBlocks, want, single.StateGB)
+	}
+
+	// The state of max_num_seqs sequences must fit
+	if _, err := CalculatePagedKVCache(0, kv, ParallelConfig{}, paged, []float64{0.01}); err == nil {
+		t.Error("expected an error when the recurrent state doesn't fit")
+	}
+}
+
+func TestPagedKVCacheEncoderDecoder(t *testing.T) {
+	// Whisper's decoder cross-attends to all 1500 encoder frames
+	config := mustParseConfig(t, whisperConfig)
+	kv := KVCacheParams{Users: 4, ContextLength: 448, DecoderOutputLength: 448, KVDataType: Float16, Config: config}
+	paged := DefaultPagedKVCacheParams()
+
+	// 28 blocks of decoder output and 94 of encoder input, each 2.5 MiB
+	if got := paged.blocksPerRequest(kv); got != 28+94 {
+		t.Errorf("blocksPerRequest() = %d, want %d", got, 28+94)
+	}
+	cache, err := CalculatePagedKVCache(0, kv, ParallelConfig{}, paged, []float64{10})
+	if err != nil {
+		t.Fatalf("CalculatePagedKVCache: %v", err)
+	}
+	if cache.NumBlocks != 4096 {
+		t.Errorf("NumBlocks = %d, want 4096", cache.NumBlocks)
+	}
+	if want := 65536.0 / (448 + 1500); cache.MaxConcurrency != want {
+		t.Errorf("MaxConcurrency = %.2f, want %.2f", cache.MaxConcurrency, want)
+	}
+
+	estimate, err := NewVLLMEngine(paged).Estimate(EngineParams{
+		ParametersB: 1.55,
+		WeightsGB:   3.1,
+		KV:          kv,
+		Parallel:    ParallelConfig{TensorParallel: 1, PipelineParallel: 1},
+	})
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if want := 4 * (28 + 94) * 16; estimate.RequiredTokens != want {
+		t.Errorf("RequiredTokens = %d, want %d", estimate.RequiredTokens, want)
+	}
+}
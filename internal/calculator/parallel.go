@@ -0,0 +1,181 @@
+// internal/calculator/parallel.go
+
+package calculator
+
+import "fmt"
+
+// ParallelConfig describes how a model is split across GPUs
+type ParallelConfig struct {
+	TensorParallel   int // GPUs each layer is split across
+	PipelineParallel int // Stages the layers are divided into
+}
+
+// GPUs returns the total number of GPUs
+func (p ParallelConfig) GPUs() int {
+	return max(p.TensorParallel, 1) * max(p.PipelineParallel, 1)
+}
+
+// Validate checks that the parallel sizes are usable
+func (p ParallelConfig) Validate() error {
+	if p.TensorParallel < 1 || p.PipelineParallel < 1 {
+		return fmt.Errorf("tensor and pipeline parallel sizes must be at least 1")
+	}
+	return nil
+}
+
+// ParallelParams holds parameters for splitting memory across GPUs
+type ParallelParams struct {
+	ParametersB   float64 // Total parameters in billions
+	WeightsGB     float64 // Single-GPU weight memory from CalculateWeightMemory
+	ActivationsGB float64 // Activations on each GPU from CalculateActivationMemory
+	KVCacheGB     float64 // Single-GPU KV cache, split evenly when KV.Config is nil
+	KV            KVCacheParams
+	Parallel      ParallelConfig
+	Overhead      OverheadModel // Applied to every GPU
+	Draft         DraftMemory   // Speculative decoding, split over the tensor-parallel ranks of the last stage
+}
+
+// CalculateParallelMemory splits weights and KV cache over tensor- and pipeline-
+// parallel ranks and returns one breakdown per pipeline stage; all tensor-parallel
+// ranks within a stage hold the same amount.
+//
+// Embeddings and the LM head are split over the vocabulary like vLLM's
+// VocabParallelEmbedding and ParallelLMHead, norms are replicated on every
+// tensor-parallel rank of the stage that holds them, and KV heads are replicated
+// when there are fewer KV heads than tensor-parallel ranks.
+func CalculateParallelMemory(params ParallelParams) ([]MemoryBreakdown, error) {
+	if err := params.Parallel.Validate(); err != nil {
+		return nil, err
+	}
+
+	tp := params.Parallel.TensorParallel
+	pp := params.Parallel.PipelineParallel
+	config := params.KV.Config
+
+	if config == nil || config.NumHiddenLayers == 0 || params.ParametersB == 0 {
+		// Without the architecture, split everything evenly
+		gpus := float64(params.Parallel.GPUs())
+		breakdowns := make([]MemoryBreakdown, pp)
+		for stage := range breakdowns {
+			weights, kv := params.WeightsGB/gpus, params.KVCacheGB/gpus
+			if stage == pp-1 {
+				weights, kv = params.draftShare(weights, kv)
+			}
+			breakdowns[stage] = NewMemoryBreakdown(stageLabel(stage, pp, tp), tp,
+				weights, params.ActivationsGB, kv, params.Overhead)
+		}
+		return breakdowns, nil
+	}
+	if pp > config.NumHiddenLayers {
+		return nil, fmt.Errorf("pipeline parallel size %d exceeds the %d layers of the model",
+			pp, config.NumHiddenLayers)
+	}
+
+	shares := config.weightShares(params.ParametersB)
+	kvDataType := params.KV.kvDataType()
+	kvBytes, ok := BytesPerParameter(kvDataType)
+	if !ok || !ValidateKVDataType(kvDataType) {
+		return nil, ErrUnsupportedDataType{kvDataType}
+	}
+
+	layers := config.NumHiddenLayers
+	breakdowns := make([]MemoryBreakdown, pp)
+	for stage, r := range pipelineStages(layers, pp) {
+		layerFraction := float64(r.last-r.first) / float64(layers)
+		share := shares.layers*layerFraction/float64(tp) + shares.norms*layerFraction
+		if stage == 0 {
+			share += shares.embeddings / float64(tp)
+		}
+		if stage == pp-1 && (!config.tiedEmbeddings() || pp > 1) {
+			share += shares.lmHead / float64(tp)
+		}
+
+		elements := config.kvCacheElementsPerRank(params.KV.sequence(), r.first, r.last, tp)
+		state := config.stateElementsPerRank(r.first, r.last, tp)
+		perUser := elements*kvBytes + state*params.KV.stateBytes()
+		weights, kvGB := params.WeightsGB*share, perUser*float64(params.KV.Users)/bytesPerGiB
+		if stage == pp-1 {
+			weights, kvGB = params.draftShare(weights, kvGB)
+		}
+
+		breakdowns[stage] = NewMemoryBreakdown(stageLabel(stage, pp, tp), tp,
+			weights, params.ActivationsGB, kvGB, params.Overhead)
+	}
+
+	return breakdowns, nil
+}
+
+// draftShare adds a tensor-parallel rank's share of the draft model to the
+// weights and KV cache of a GPU in the last stage
+func (p ParallelParams) draftShare(weightsGB, kvCacheGB float64) (float64, float64) {
+	tp := float64(max(p.Parallel.TensorParallel, 1))
+	draftKV := p.Draft.KVCacheGB / tp
+	if p.Draft.KVCachePerRankGB > 0 {
+		draftKV = p.Draft.KVCachePerRankGB
+	}
+	return weightsGB + p.Draft.WeightsGB/tp, kvCacheGB + draftKV
+}
+
+// layerRange is the half-open range of decoder layers held by a pipeline stage
+type layerRange struct {
+	first, last int
+}
+
+// pipelineStages divides the layers over the pipeline stages. Earlier stages
+// take the remainder when layers don't divide evenly.
+func pipelineStages(layers, stages int) []layerRange {
+	ranges := make([]layerRange, stages)
+	first := 0
+	for stage := range ranges {
+		count := layers / stages
+		if stage < layers%stages {
+			count++
+		}
+		ranges[stage] = layerRange{first, first + count}
+		first += count
+	}
+	return ranges
+}
+
+// stageLabel names the GPUs of a pipeline stage
+func stageLabel(stage, stages, tensorParallel int) string {
+	if stages == 1 {
+		return fmt.Sprintf("TP x%d", tensorParallel)
+	}
+	return fmt.Sprintf("PP stage %d (TP x%d)", stage, tensorParallel)
+}
+
+// weightShares holds the fraction of the model's parameters in each group
+type weightShares struct {
+	embeddings float64
+	lmHead     float64
+	norms      float64
+	layers     float64 // Decoder layer weights
+}
+
+// weightShares splits the parameter count into embeddings, norms and layers.
+// A tied LM head is counted once but still needs a copy on the last pipeline stage.
+func (c *ModelConfig) weightShares(parametersB float64) weightShares {
+	total := parametersB * 1e9
+	embeddings := float64(c.VocabSize) * float64(c.HiddenSize)
+	norms := float64(2*c.NumHiddenLayers+1) * float64(c.HiddenSize)
+
+	lmHead := embeddings
+	stored := 2 * embeddings
+	if c.tiedEmbeddings() {
+		stored = embeddings
+	}
+
+	layers := total - stored - norms
+	if layers <= 0 {
+		// Config and parameter count disagree, treat everything as shardable
+		return weightShares{layers: 1}
+	}
+
+	return weightShares{
+		embeddings: embeddings / total,
+		lmHead:     lmHead / total,
+		norms:      norms / total,
+		layers:     layers / total,
+	}
+}
@@ -0,0 +1,114 @@
+// internal/calculator/parallel_test.go
+
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateParallelMemory(t *testing.T) {
+	config := llama3Config()
+	const weightsGB = 16.06 // 8.03B in bfloat16
+	kv := KVCacheParams{Users: 4, ContextLength: 8192, KVDataType: BFloat16, Config: config}
+	kvGB, err := CalculateKVCache(kv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Embeddings and the LM head are split over the vocabulary, norms are replicated
+	embeddingsGB := 128256 * 4096 * 2 / 1e9
+	normsGB := 65 * 4096 * 2 / 1e9
+	layersGB := weightsGB - 2*embeddingsGB - normsGB
+
+	tests := []struct {
+		name     string
+		parallel ParallelConfig
+		weights  []float64 // Per GPU of each stage
+		kvCache  []float64
+	}{
+		{
+			name:     "single gpu",
+			parallel: ParallelConfig{TensorParallel: 1, PipelineParallel: 1},
+			weights:  []float64{weightsGB},
+			kvCache:  []float64{kvGB},
+		},
+		{
+			name:     "tensor parallel",
+			parallel: ParallelConfig{TensorParallel: 2, PipelineParallel: 1},
+			weights:  []float64{(layersGB+2*embeddingsGB)/2 + normsGB},
+			kvCache:  []float64{kvGB / 2},
+		},
+		{
+			name:     "more ranks than KV heads replicates them",
+			parallel: ParallelConfig{TensorParallel: 16, PipelineParallel: 1},
+			weights:  []float64{(layersGB+2*embeddingsGB)/16 + normsGB},
+			kvCache:  []float64{kvGB / 8},
+		},
+		{
+			name:     "pipeline parallel",
+			parallel: ParallelConfig{TensorParallel: 1, PipelineParallel: 2},
+			weights:  []float64{layersGB/2 + embeddingsGB + normsGB/2, layersGB/2 + embeddingsGB + normsGB/2},
+			kvCache:  []float64{kvGB / 2, kvGB / 2},
+		},
+		{
+			name:     "tensor and pipeline parallel",
+			parallel: ParallelConfig{TensorParallel: 2, PipelineParallel: 2},
+			weights:  []float64{layersGB/4 + embeddingsGB/2 + normsGB/2, layersGB/4 + embeddingsGB/2 + normsGB/2},
+			kvCache:  []float64{kvGB / 4, kvGB / 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdowns, err := CalculateParallelMemory(ParallelParams{
+				ParametersB: 8.03,
+				WeightsGB:   weightsGB,
+				KVCacheGB:   kvGB,
+				KV:          kv,
+				Parallel:    tt.parallel,
+			})
+			if err != nil {
+				t.Fatalf("CalculateParallelMemory: %v", err)
+			}
+			if len(breakdowns) != tt.parallel.PipelineParallel {
+				t.Fatalf("got %d breakdowns, want one per stage", len(breakdowns))
+			}
+			for stage, b := range breakdowns {
+				if b.GPUs != tt.parallel.TensorParallel {
+					t.Errorf("stage %d: GPUs = %d, want %d", stage, b.GPUs, tt.parallel.TensorParallel)
+				}
+				if math.Abs(b.WeightsGB-tt.weights[stage]) > 0.01 {
+					t.Errorf("stage %d: WeightsGB = %.2f, want %.2f", stage, b.WeightsGB, tt.weights[stage])
+				}
+				if math.Abs(b.KVCacheGB-tt.kvCache[stage]) > 0.01 {
+					t.Errorf("stage %d: KVCacheGB = %.2f, want %.2f", stage, b.KVCacheGB, tt.kvCache[stage])
+				}
+			}
+		})
+	}
+
+	if _, err := CalculateParallelMemory(ParallelParams{ParametersB: 8.03, WeightsGB: weightsGB, KV: kv,
+		Parallel: ParallelConfig{TensorParallel: 1, PipelineParallel: 33}}); err == nil {
+		t.Error("expected an error for more pipeline stages than layers")
+	}
+	if _, err := CalculateParallelMemory(ParallelParams{Parallel: ParallelConfig{}}); err == nil {
+		t.Error("expected an error for parallel sizes below 1")
+	}
+}
+
+func TestCalculateParallelMemoryWithoutConfig(t *testing.T) {
+	breakdowns, err := CalculateParallelMemory(ParallelParams{
+		WeightsGB: 16,
+		KVCacheGB: 8,
+		Parallel:  ParallelConfig{TensorParallel: 2, PipelineParallel: 2},
+	})
+	if err != nil {
+		t.Fatalf("CalculateParallelMemory: %v", err)
+	}
+	for stage, b := range breakdowns {
+		if b.WeightsGB != 4 || b.KVCacheGB != 2 {
+			t.Errorf("stage %d: WeightsGB, KVCacheGB = %.2f, %.2f, want an even split of 4, 2", stage, b.WeightsGB, b.KVCacheGB)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// internal/calculator/parameters.go
+
+package calculator
+
+import (
+	"math"
+	"strings"
+)
+
+// ungatedMLPFamilies lists families whose MLP is a plain up and down
+// projection rather than a gated (SwiGLU/GeGLU) gate, up and down projection
+var ungatedMLPFamilies = map[string]bool{
+	"gpt2":                 true,
+	"gpt_neox":             true,
+	"gptj":                 true,
+	"opt":                  true,
+	"bloom":                true,
+	"falcon":               true,
+	"refinedweb":           true,
+	"refinedwebmodel":      true,
+	"mpt":                  true,
+	"phi":                  true,
+	"phi-msft":             true,
+	"mixformer-sequential": true,
+	"starcoder2":           true,
+	"gpt_bigcode":          true,
+	"bart":                 true,
+	"mbart":                true,
+	"whisper":              true,
+	"marian":               true,
+	"pegasus":              true,
+	"t5":                   true,
+}
+
+// gatedMLP reports whether the MLP has a gate projection. T5 names gated
+// variants in feed_forward_proj (gated-gelu), others follow their family.
+func (c *ModelConfig) gatedMLP() bool {
+	if c.FeedForwardProj != "" {
+		return strings.HasPrefix(c.FeedForwardProj, "gated")
+	}
+	return !ungatedMLPFamilies[c.family()]
+}
+
+// tiedEmbeddings reports whether the LM head shares the embedding matrix. When
+// config.json omits tie_word_embeddings the family's default applies, which
+// transformers ties unless the family is registered as untied.
+func (c *ModelConfig) tiedEmbeddings() bool {
+	if c.TieWordEmbeddings != nil {
+		return *c.TieWordEmbeddings
+	}
+	return !configDialects[c.family()].untiedEmbeddings
+}
+
+// EstimateParameters derives the parameter count from the config for
+// checkpoints without safetensors metadata. It counts:
+//   - the embeddings, and the LM head unless tied
+//   - per layer: the q/k/v/o projections with GQA, or the MLA projections,
+//     the gated or plain MLP, or the experts, shared experts and router of MoE
+//     layers, the Mamba or RWKV mixer of state-space layers and two norms
+//   - the encoder and the decoder's cross-attention of encoder-decoder models
+//   - multi-token prediction layers and the vision encoder
+//
+// Biases are ignored. It returns false when the config lacks the dimensions.
+func (c *ModelConfig) EstimateParameters() (int64, bool) {
+	if c.HiddenSize == 0 || c.NumHiddenLayers == 0 || c.VocabSize == 0 {
+		return 0, false
+	}
+	hidden := float64(c.HiddenSize)
+
+	embeddings := float64(c.VocabSize) * hidden
+	total := embeddings + hidden // Final norm
+	if !c.tiedEmbeddings() {
+		total += embeddings
+	}
+
+	kinds := c.layerKinds()
+	for i, kind := range kinds {
+		total += 2 * hidden // Norms
+		switch kind {
+		case attentionSSM:
+			total += c.mixerParameters()
+		case attentionHybrid:
+			total += c.mixerParameters() + c.attentionParameters()
+		default:
+			total += c.attentionParameters()
+		}
+		if c.IsEncoderDecoder {
+			total += c.attentionParameters() // Cross-attention
+		}
+		if stateSpaceFamilies[c.family()] && !rwkvFamilies[c.family()] {
+			continue // Mamba layers are the mixer alone
+		}
+
+		if c.IsMoE() && c.isMoELayer(i) {
+			total += c.moeMLPParameters()
+		} else {
+			total += c.mlpParameters(c.IntermediateSize)
+		}
+	}
+
+	if c.IsEncoderDecoder {
+		perLayer := 2*hidden + c.attentionParameters() + c.mlpParameters(c.IntermediateSize)
+		total += float64(c.EncoderLayers)*perLayer + hidden
+	}
+
+	if c.NumNextnPredictLayers > 0 {
+		// Each MTP layer is a decoder layer fed the concatenated hidden state and
+		// next-token embedding through a 2h x h projection and two extra norms
+		perLayer := 4*hidden + 2*hidden*hidden + c.attentionParameters() + c.moeMLPParameters()
+		total += float64(c.NumNextnPredictLayers) * perLayer
+	}
+
+	if vision, ok := c.VisionEncoder(); ok {
+		total += vision.ParamsB * 1e9
+	}
+
+	return int64(total), true
+}
+
+// attentionParameters returns the parameters of one attention block
+func (c *ModelConfig) attentionParameters() float64 {
+	hidden := float64(c.HiddenSize)
+	heads := float64(c.NumAttentionHeads)
+
+	if c.usesLatentAttention() {
+		nope := float64(c.QKNopeHeadDim)
+		rope := float64(c.QKRopeHeadDim)
+		value := float64(firstPositive(c.VHeadDim, c.QKNopeHeadDim))
+		rank := float64(c.KVLoraRank)
+
+		q := hidden * heads * (nope + rope)
+		if c.QLoraRank > 0 {
+			qRank := float64(c.QLoraRank)
+			q = hidden*qRank + qRank + qRank*heads*(nope+rope)
+		}
+		kv := hidden*(rank+rope) + rank + rank*heads*(nope+value)
+		return q + kv + heads*value*hidden
+	}
+
+	headDim := float64(c.headDim())
+	attention := heads * headDim
+	kv := float64(c.NumKeyValueHeads) * headDim
+	return 2*hidden*attention + 2*hidden*kv
+}
+
+// mlpParameters returns the parameters of one MLP of the given width
+func (c *ModelConfig) mlpParameters(intermediate int) float64 {
+	projections := 2.0
+	if c.gatedMLP() {
+		projections = 3
+	}
+	return projections * float64(c.HiddenSize) * float64(intermediate)
+}
+
+// moeMLPParameters returns the routed experts, shared experts and router of
+// one MoE layer
+func (c *ModelConfig) moeMLPParameters() float64 {
+	if !c.IsMoE() {
+		return c.mlpParameters(c.IntermediateSize)
+	}
+	expertSize := c.MoEIntermediateSize
+	if expertSize == 0 {
+		expertSize = c.IntermediateSize
+	}
+
+	experts := float64(c.numExperts()) * c.mlpParameters(expertSize)
+	router := float64(c.HiddenSize) * float64(c.numExperts())
+	shared := float64(c.NSharedExperts) * c.mlpParameters(expertSize)
+	if c.SharedExpertIntermediateSize > 0 {
+		shared = c.mlpParameters(c.SharedExpertIntermediateSize)
+	}
+	return experts + router + shared
+}
+
+// mixerParameters returns the parameters of a Mamba mixer, or of RWKV's time
+// and channel mixing
+func (c *ModelConfig) mixerParameters() float64 {
+	hidden := float64(c.HiddenSize)
+
+	if rwkvFamilies[c.family()] {
+		attention := float64(firstPositive(c.AttentionHiddenSize, c.HiddenSize))
+		intermediate := float64(firstPositive(c.IntermediateSize, 4*c.HiddenSize))
+		return 4*hidden*attention + 2*hidden*intermediate + hidden*hidden
+	}
+
+	inner := c.mambaInnerSize()
+	state := float64(c.mambaStateSize())
+	conv := float64(firstPositive(c.ConvKernel, c.DConv, c.MambaDConv))
+	groups := float64(firstPositive(c.NGroups, c.MambaNGroups))
+
+	// Input projection to x and z, output projection, the convolution and the
+	// per-channel state parameters, plus Mamba-2's grouped B and C projections
+	mixer := 3*hidden*inner + inner*(conv+2*state) + 2*groups*state*hidden
+	if groups == 0 {
+		// Mamba-1 projects x to a low-rank time step of hidden_size / 16 and back
+		dtRank := math.Ceil(hidden / 16)
+		mixer += 2 * inner * dtRank
+	}
+	return mixer
+}
@@ -0,0 +1,117 @@
+// internal/calculator/parameters_test.go
+
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateParameters(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *ModelConfig
+		wantB  float64 // Published parameter count
+	}{
+		{
+			name: "gpt2",
+			config: &ModelConfig{ModelType: "gpt2", HiddenSize: 768, NumHiddenLayers: 12,
+				NumAttentionHeads: 12, NumKeyValueHeads: 12, IntermediateSize: 3072,
+				VocabSize: 50257},
+			wantB: 0.124,
+		},
+		{
+			name: "bloom-560m",
+			config: &ModelConfig{ModelType: "bloom", HiddenSize: 1024, NumHiddenLayers: 24,
+				NumAttentionHeads: 16, NumKeyValueHeads: 16, IntermediateSize: 4096,
+				VocabSize: 250880},
+			wantB: 0.559,
+		},
+		{
+			name:   "Meta-Llama-3-8B",
+			config: llama3Config(),
+			wantB:  8.03,
+		},
+		{
+			name:   "Mixtral-8x7B",
+			config: func() *ModelConfig { c := mixtralConfig(); c.VocabSize = 32000; return c }(),
+			wantB:  46.70,
+		},
+		{
+			name: "DeepSeek-V2-Lite",
+			config: &ModelConfig{ModelType: "deepseek_v2", HiddenSize: 2048, IntermediateSize: 10944,
+				MoEIntermediateSize: 1408, NumHiddenLayers: 27, NumAttentionHeads: 16,
+				NumKeyValueHeads: 16, NRoutedExperts: 64, NSharedExperts: 2, NumExpertsPerTok: 6,
+				FirstKDenseReplace: 1, KVLoraRank: 512, QKRopeHeadDim: 64, QKNopeHeadDim: 128,
+				VHeadDim: 128, VocabSize: 102400},
+			wantB: 15.71,
+		},
+		{
+			name: "t5-base",
+			config: &ModelConfig{ModelType: "t5", HiddenSize: 768, IntermediateSize: 3072,
+				HeadDim: 64, NumAttentionHeads: 12, NumKeyValueHeads: 12, NumHiddenLayers: 12,
+				EncoderLayers: 12, IsEncoderDecoder: true, FeedForwardProj: "relu",
+				VocabSize: 32128},
+			wantB: 0.223,
+		},
+		{
+			// tie_word_embeddings is omitted and Gemma ties by default
+			name: "gemma-7b",
+			config: &ModelConfig{ModelType: "gemma", HiddenSize: 3072, IntermediateSize: 24576,
+				NumHiddenLayers: 28, NumAttentionHeads: 16, NumKeyValueHeads: 16, HeadDim: 256,
+				VocabSize: 256000},
+			wantB: 8.54,
+		},
+		{
+			name:   "mamba-2.8b",
+			config: mambaConfig(),
+			wantB:  2.77,
+		},
+		{
+			name:   "Jamba-v0.1",
+			config: jambaConfig(),
+			wantB:  51.57,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, ok := tt.config.EstimateParameters()
+			if !ok {
+				t.Fatal("EstimateParameters: config lacks dimensions")
+			}
+			// Biases are left out, so allow 1%
+			gotB := float64(params) / 1e9
+			if math.Abs(gotB-tt.wantB) > 0.01*tt.wantB {
+				t.Errorf("EstimateParameters() = %.3fB, want %.3fB", gotB, tt.wantB)
+			}
+		})
+	}
+}
+
+func TestTiedEmbeddings(t *testing.T) {
+	tied, untied := true, false
+	tests := []struct {
+		name   string
+		config *ModelConfig
+		want   bool
+	}{
+		{"explicitly tied llama", &ModelConfig{ModelType: "llama", TieWordEmbeddings: &tied}, true},
+		{"llama defaults to untied", &ModelConfig{ModelType: "llama"}, false},
+		{"gemma defaults to tied", &ModelConfig{ModelType: "gemma"}, true},
+		{"explicitly untied gemma", &ModelConfig{ModelType: "gemma", TieWordEmbeddings: &untied}, false},
+		{"unknown families follow transformers", &ModelConfig{ModelType: "new_model"}, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.config.tiedEmbeddings(); got != tt.want {
+			t.Errorf("%s: tiedEmbeddings() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateParametersMissingDimensions(t *testing.T) {
+	if _, ok := (&ModelConfig{ModelType: "llama", HiddenSize: 4096}).EstimateParameters(); ok {
+		t.Error("EstimateParameters() succeeded without layers or vocabulary")
+	}
+}
@@ -0,0 +1,193 @@
+// internal/calculator/quantization.go
+
+package calculator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QuantizationConfig represents the quantization_config block of config.json
+type QuantizationConfig struct {
+	QuantMethod string  `json:"quant_method"`
+	Bits        float64 `json:"bits"`
+	GroupSize   int     `json:"group_size"`
+
+	// Legacy AutoAWQ field names
+	WBit       float64 `json:"w_bit"`
+	QGroupSize int     `json:"q_group_size"`
+
+	// bitsandbytes
+	LoadIn4Bit            bool   `json:"load_in_4bit"`
+	LoadIn8Bit            bool   `json:"load_in_8bit"`
+	BnB4BitQuantType      string `json:"bnb_4bit_quant_type"`
+	BnB4BitUseDoubleQuant bool   `json:"bnb_4bit_use_double_quant"`
+
+	// FP8
+	Fmt             string `json:"fmt"`
+	WeightBlockSize []int  `json:"weight_block_size"`
+}
+
+// bits returns the weight bit width, accepting legacy field names
+func (q *QuantizationConfig) bits() float64 {
+	if q.Bits > 0 {
+		return q.Bits
+	}
+	return q.WBit
+}
+
+// groupSize returns the quantization group size, accepting legacy field names
+func (q *QuantizationConfig) groupSize() int {
+	if q.GroupSize != 0 {
+		return q.GroupSize
+	}
+	return q.QGroupSize
+}
+
+// String describes the quantization, e.g. "awq, 4 bits, group size 128"
+func (q *QuantizationConfig) String() string {
+	parts := []string{strings.ToLower(q.QuantMethod)}
+	switch {
+	case q.LoadIn4Bit:
+		parts = append(parts, strings.TrimSpace("4-bit "+q.BnB4BitQuantType))
+	case q.LoadIn8Bit:
+		parts = append(parts, "8-bit")
+	case q.bits() > 0:
+		parts = append(parts, fmt.Sprintf("%g bits", q.bits()))
+	}
+	if q.groupSize() > 0 {
+		parts = append(parts, fmt.Sprintf("group size %d", q.groupSize()))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// packed reports whether the method packs several weights into each int32 or
+// uint8 safetensors element
+func (q *QuantizationConfig) packed() bool {
+	switch strings.ToLower(q.QuantMethod) {
+	case "gptq", "awq", "marlin", "exl2":
+		return true
+	case "bitsandbytes":
+		return q.LoadIn4Bit
+	}
+	return false
+}
+
+// DataType returns the effective weight data type of the quantized checkpoint.
+// Group-wise methods store an fp16 scale and a packed zero point per group, so
+// their effective bits per weight exceed the nominal bit width.
+func (q *QuantizationConfig) DataType() (DataType, bool) {
+	const (
+		scaleBits     = 16   // fp16 scale per group
+		absmaxBits    = 32   // fp32 absmax per bitsandbytes block
+		bnbBlockSize  = 64   // bitsandbytes 4-bit block size
+		bnbDoubleBits = 8    // quantized absmax with double quantization
+		bnbSuperBlock = 256  // blocks sharing one fp32 scale with double quantization
+		fp8ScaleBits  = 32.0 // fp32 scale per weight block
+	)
+
+	switch strings.ToLower(q.QuantMethod) {
+	case "gptq", "awq", "marlin":
+		bits := q.bits()
+		if bits <= 0 {
+			return "", false
+		}
+		if group := q.groupSize(); group > 0 {
+			bits += (scaleBits + bits) / float64(group)
+		}
+		return BitsPerWeightType(bits), true
+
+	case "exl2":
+		if q.bits() <= 0 {
+			return "", false
+		}
+		return BitsPerWeightType(q.bits()), true
+
+	case "bitsandbytes":
+		switch {
+		case q.LoadIn8Bit:
+			return Int8, true
+		case q.LoadIn4Bit && q.BnB4BitUseDoubleQuant:
+			bits := 4 + bnbDoubleBits/float64(bnbBlockSize) + absmaxBits/float64(bnbBlockSize*bnbSuperBlock)
+			return BitsPerWeightType(bits), true
+		case q.LoadIn4Bit && strings.ToLower(q.BnB4BitQuantType) == string(FP4):
+			return FP4, true
+		case q.LoadIn4Bit:
+			return NF4, true
+		}
+
+	case "fp8", "fbgemm_fp8":
+		dtype := FP8E4M3
+		if strings.ToLower(q.Fmt) == "e5m2" {
+			dtype = FP8E5M2
+		}
+		if len(q.WeightBlockSize) == 2 && q.WeightBlockSize[0]*q.WeightBlockSize[1] > 0 {
+			block := float64(q.WeightBlockSize[0] * q.WeightBlockSize[1])
+			return BitsPerWeightType(8 + fp8ScaleBits/block), true
+		}
+		return dtype, true
+	}
+
+	return "", false
+}
+
+// CheckpointDataType returns the data type the checkpoint's weights are stored in,
+// preferring quantization_config over torch_dtype
+func (c *ModelConfig) CheckpointDataType() (DataType, bool) {
+	if c.QuantizationConfig != nil {
+		if dtype, ok := c.QuantizationConfig.DataType(); ok {
+			return dtype, true
+		}
+	}
+
+	if c.TorchDtype != "" {
+		dtype := NormalizeDataType(DataType(c.TorchDtype))
+		if ValidateDataType(dtype) {
+			return dtype, true
+		}
+	}
+
+	return "", false
+}
+
+// UnpackedParameters derives the parameter count of a packed checkpoint (GPTQ,
+// AWQ, EXL2, bitsandbytes 4-bit) from config.json, as safetensors counts each
+// packed element as one parameter. It returns false for other checkpoints.
+func (c *ModelConfig) UnpackedParameters() (int64, bool) {
+	if c.QuantizationConfig == nil || !c.QuantizationConfig.packed() {
+		return 0, false
+	}
+	return c.EstimateParameters()
+}
+
+// CalculateQuantizedWeightMemory calculates the weight memory like
+// CalculateWeightMemory, except that in the checkpoint's own quantization the
+// embeddings and LM head stay at torch_dtype width, as quantizers leave them
+// unquantized
+func CalculateQuantizedWeightMemory(config *ModelConfig, parameters float64, dtype DataType) (float64, error) {
+	if config == nil || config.QuantizationConfig == nil {
+		return CalculateWeightMemory(parameters, dtype)
+	}
+	if quantized, ok := config.QuantizationConfig.DataType(); !ok || quantized != dtype {
+		return CalculateWeightMemory(parameters, dtype)
+	}
+
+	bytes, ok := BytesPerParameter(dtype)
+	if !ok {
+		return 0, ErrUnsupportedDataType{dtype}
+	}
+	unquantized := NormalizeDataType(DataType(config.TorchDtype))
+	unquantizedBytes, ok := BytesPerParameter(unquantized)
+	if !ok {
+		unquantizedBytes, _ = BytesPerParameter(Float16)
+	}
+
+	embeddings := float64(config.VocabSize) * float64(config.HiddenSize) / 1e9
+	if !config.tiedEmbeddings() {
+		embeddings *= 2 // LM head
+	}
+	embeddings = min(max(embeddings, 0), parameters) // Sizes of an invalid config stay in range
+
+	memory := embeddings*unquantizedBytes + (parameters-embeddings)*bytes
+	return round(memory, 2), nil
+}
@@ -0,0 +1,196 @@
+// internal/calculator/quantization_test.go
+
+package calculator
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCheckpointDataType(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   DataType
+		ok     bool
+	}{
+		{
+			// TheBloke/Llama-2-7B-AWQ
+			name:   "awq 4-bit group 128",
+			config: `{"torch_dtype": "float16", "quantization_config": {"quant_method": "awq", "zero_point": true, "group_size": 128, "bits": 4, "version": "gemm"}}`,
+			want:   "4.156bpw",
+			ok:     true,
+		},
+		{
+			name:   "legacy autoawq field names",
+			config: `{"quantization_config": {"quant_method": "awq", "w_bit": 4, "q_group_size": 128, "zero_point": true}}`,
+			want:   "4.156bpw",
+			ok:     true,
+		},
+		{
+			// TheBloke/Llama-2-7B-GPTQ
+			name:   "gptq 4-bit group 128",
+			config: `{"torch_dtype": "float16", "quantization_config": {"bits": 4, "group_size": 128, "damp_percent": 0.1, "desc_act": false, "sym": true, "true_sequential": true, "quant_method": "gptq"}}`,
+			want:   "4.156bpw",
+			ok:     true,
+		},
+		{
+			name:   "gptq 8-bit group 128",
+			config: `{"quantization_config": {"bits": 8, "group_size": 128, "quant_method": "gptq"}}`,
+			want:   "8.188bpw",
+			ok:     true,
+		},
+		{
+			name:   "gptq per-channel",
+			config: `{"quantization_config": {"bits": 4, "group_size": -1, "quant_method": "gptq"}}`,
+			want:   "4bpw",
+			ok:     true,
+		},
+		{
+			name:   "exl2",
+			config: `{"quantization_config": {"quant_method": "exl2", "bits": 4.65}}`,
+			want:   "4.65bpw",
+			ok:     true,
+		},
+		{
+			name:   "bitsandbytes 8-bit",
+			config: `{"quantization_config": {"quant_method": "bitsandbytes", "load_in_8bit": true}}`,
+			want:   Int8,
+			ok:     true,
+		},
+		{
+			name:   "bitsandbytes nf4",
+			config: `{"quantization_config": {"quant_method": "bitsandbytes", "load_in_4bit": true, "bnb_4bit_quant_type": "nf4"}}`,
+			want:   NF4,
+			ok:     true,
+		},
+		{
+			name:   "bitsandbytes fp4",
+			config: `{"quantization_config": {"quant_method": "bitsandbytes", "load_in_4bit": true, "bnb_4bit_quant_type": "fp4"}}`,
+			want:   FP4,
+			ok:     true,
+		},
+		{
+			// unsloth/llama-3-8b-bnb-4bit
+			name:   "bitsandbytes nf4 double quant",
+			config: `{"torch_dtype": "bfloat16", "quantization_config": {"quant_method": "bitsandbytes", "load_in_4bit": true, "bnb_4bit_quant_type": "nf4", "bnb_4bit_use_double_quant": true, "bnb_4bit_compute_dtype": "bfloat16"}}`,
+			want:   "4.127bpw",
+			ok:     true,
+		},
+		{
+			// deepseek-ai/DeepSeek-V3
+			name:   "block-wise fp8",
+			config: `{"torch_dtype": "bfloat16", "quantization_config": {"activation_scheme": "dynamic", "fmt": "e4m3", "quant_method": "fp8", "weight_block_size": [128, 128]}}`,
+			want:   "8.002bpw",
+			ok:     true,
+		},
+		{
+			name:   "per-tensor fp8 e5m2",
+			config: `{"quantization_config": {"quant_method": "fp8", "fmt": "e5m2"}}`,
+			want:   FP8E5M2,
+			ok:     true,
+		},
+		{
+			name:   "unknown method falls back to torch_dtype",
+			config: `{"torch_dtype": "bfloat16", "quantization_config": {"quant_method": "hqq"}}`,
+			want:   BFloat16,
+			ok:     true,
+		},
+		{
+			name:   "torch_dtype only",
+			config: `{"torch_dtype": "float32"}`,
+			want:   Float32,
+			ok:     true,
+		},
+		{
+			name:   "nothing to detect",
+			config: `{"torch_dtype": "complex64"}`,
+			ok:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config ModelConfig
+			if err := json.Unmarshal([]byte(tt.config), &config); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			got, ok := config.CheckpointDataType()
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("CheckpointDataType() = %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestQuantizationConfigString(t *testing.T) {
+	tests := []struct {
+		config QuantizationConfig
+		want   string
+	}{
+		{QuantizationConfig{QuantMethod: "AWQ", Bits: 4, GroupSize: 128}, "awq, 4 bits, group size 128"},
+		{QuantizationConfig{QuantMethod: "awq", WBit: 4, QGroupSize: 64}, "awq, 4 bits, group size 64"},
+		{QuantizationConfig{QuantMethod: "bitsandbytes", LoadIn4Bit: true, BnB4BitQuantType: "nf4"}, "bitsandbytes, 4-bit nf4"},
+		{QuantizationConfig{QuantMethod: "bitsandbytes", LoadIn8Bit: true}, "bitsandbytes, 8-bit"},
+		{QuantizationConfig{QuantMethod: "fp8"}, "fp8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.config.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateQuantizedWeightMemory(t *testing.T) {
+	// TheBloke/Llama-2-7B-AWQ, whose model.safetensors is 3.89 GB
+	config := mustParseConfig(t, `{"model_type": "llama", "hidden_size": 4096,
+		"intermediate_size": 11008, "num_hidden_layers": 32, "num_attention_heads": 32,
+		"num_key_value_heads": 32, "vocab_size": 32000, "tie_word_embeddings": false,
+		"torch_dtype": "float16", "quantization_config": {"quant_method": "awq",
+		"zero_point": true, "group_size": 128, "bits": 4, "version": "gemm"}}`)
+
+	// Safetensors counts the packed int32 weights, about 1.1B in total
+	params, ok := config.UnpackedParameters()
+	if !ok {
+		t.Fatal("UnpackedParameters: AWQ not recognized as packed")
+	}
+	if params != 6738415616 {
+		t.Errorf("UnpackedParameters() = %d, want 6738415616 as in Llama-2-7b-hf", params)
+	}
+	parametersB := float64(params) / 1e9
+
+	dtype, _ := config.CheckpointDataType()
+	tests := []struct {
+		name  string
+		dtype DataType
+		want  float64
+	}{
+		// 0.52 GB of fp16 embeddings and LM head plus 6.48B weights at 4.156 bits
+		{"checkpoint quantization", dtype, 3.89},
+		{"other data types are uniform", BFloat16, 13.48},
+		{"int4", Int4, 3.37},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CalculateQuantizedWeightMemory(config, parametersB, tt.dtype)
+			if err != nil {
+				t.Fatalf("CalculateQuantizedWeightMemory: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CalculateQuantizedWeightMemory() = %.2f GB, want %.2f GB", got, tt.want)
+			}
+		})
+	}
+
+	// bitsandbytes 8-bit stores one weight per int8 element
+	bnb8 := mustParseConfig(t, `{"model_type": "llama", "hidden_size": 4096, "num_hidden_layers": 32,
+		"num_attention_heads": 32, "vocab_size": 32000,
+		"quantization_config": {"quant_method": "bitsandbytes", "load_in_8bit": true}}`)
+	if _, ok := bnb8.UnpackedParameters(); ok {
+		t.Error("UnpackedParameters: bitsandbytes 8-bit is not packed")
+	}
+}
@@ -0,0 +1,203 @@
+// internal/calculator/sharding.go
+
+package calculator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ShardingStrategy selects which training states are partitioned across
+// data-parallel GPUs
+type ShardingStrategy string
+
+// Supported sharding strategies
+const (
+	ShardingNone       ShardingStrategy = "none"        // Plain data parallelism, every GPU holds everything
+	ShardingZeRO1      ShardingStrategy = "zero1"       // Optimizer states
+	ShardingZeRO2      ShardingStrategy = "zero2"       // Optimizer states and gradients
+	ShardingZeRO3      ShardingStrategy = "zero3"       // Optimizer states, gradients and parameters
+	ShardingFSDPFull   ShardingStrategy = "fsdp-full"   // FSDP FULL_SHARD, as ZeRO-3
+	ShardingFSDPHybrid ShardingStrategy = "fsdp-hybrid" // FSDP HYBRID_SHARD, full sharding within a node
+)
+
+// shardingStrategies lists the supported strategies in display order
+var shardingStrategies = []ShardingStrategy{
+	ShardingNone, ShardingZeRO1, ShardingZeRO2, ShardingZeRO3, ShardingFSDPFull, ShardingFSDPHybrid,
+}
+
+// shardingAliases maps alternative names to strategies
+var shardingAliases = map[string]ShardingStrategy{
+	"ddp":            ShardingNone,
+	"fsdp":           ShardingFSDPFull,
+	"full_shard":     ShardingFSDPFull,
+	"hybrid_shard":   ShardingFSDPHybrid,
+	"shard_grad_op":  ShardingZeRO2,
+	"fsdp-grad-op":   ShardingZeRO2,
+	"deepspeed-zero": ShardingZeRO3,
+}
+
+// GetShardingStrategies returns the supported sharding strategies
+func GetShardingStrategies() []ShardingStrategy {
+	return shardingStrategies
+}
+
+// ParseShardingStrategy finds a sharding strategy by name or alias, ignoring case
+func ParseShardingStrategy(name string) (ShardingStrategy, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if strategy, ok := shardingAliases[name]; ok {
+		return strategy, true
+	}
+	for _, strategy := range shardingStrategies {
+		if string(strategy) == name {
+			return strategy, true
+		}
+	}
+	return "", false
+}
+
+// DescribeShardingStrategies lists the supported sharding strategies
+func DescribeShardingStrategies() string {
+	names := make([]string, len(shardingStrategies))
+	for i, strategy := range shardingStrategies {
+		names[i] = string(strategy)
+	}
+	return strings.Join(names, ", ")
+}
+
+// shardsOptimizer reports whether optimizer states are partitioned
+func (s ShardingStrategy) shardsOptimizer() bool {
+	return s != "" && s != ShardingNone
+}
+
+// shardsGradients reports whether gradients are partitioned
+func (s ShardingStrategy) shardsGradients() bool {
+	return s.shardsOptimizer() && s != ShardingZeRO1
+}
+
+// shardsParameters reports whether parameters are partitioned
+func (s ShardingStrategy) shardsParameters() bool {
+	return s == ShardingZeRO3 || s == ShardingFSDPFull || s == ShardingFSDPHybrid
+}
+
+// ShardingParams describes how training is spread over data-parallel GPUs
+type ShardingParams struct {
+	Strategy          ShardingStrategy
+	DataParallel      int  // GPUs training replicas of the model, defaults to 1
+	GPUsPerNode       int  // Shard group size of hybrid sharding and GPUs sharing a host, defaults to 8
+	OffloadOptimizer  bool // Optimizer states live in host memory
+	OffloadParameters bool // Parameter shards live in host memory, requires parameter sharding
+}
+
+// Default number of GPUs per node
+const defaultGPUsPerNode = 8
+
+// worldSize returns the data-parallel size, defaulting to 1
+func (p ShardingParams) worldSize() int {
+	return max(p.DataParallel, 1)
+}
+
+// gpusPerNode returns the GPUs sharing a node, defaulting to 8
+func (p ShardingParams) gpusPerNode() int {
+	if p.GPUsPerNode < 1 {
+		return defaultGPUsPerNode
+	}
+	return p.GPUsPerNode
+}
+
+// shardGroup returns the number of GPUs each state is partitioned over
+func (p ShardingParams) shardGroup() int {
+	if p.Strategy == ShardingFSDPHybrid {
+		return min(p.worldSize(), p.gpusPerNode())
+	}
+	return p.worldSize()
+}
+
+// Validate checks the strategy, sizes and offload options
+func (p ShardingParams) Validate() error {
+	if p.Strategy != "" {
+		if strategy, ok := ParseShardingStrategy(string(p.Strategy)); !ok || strategy != p.Strategy {
+			return fmt.Errorf("unknown sharding strategy %q (%s)", p.Strategy, DescribeShardingStrategies())
+		}
+	}
+	if p.DataParallel < 0 || p.GPUsPerNode < 0 {
+		return fmt.Errorf("data parallel size and GPUs per node must not be negative")
+	}
+	if p.OffloadOptimizer && !p.Strategy.shardsOptimizer() {
+		return fmt.Errorf("optimizer offload requires a ZeRO or FSDP sharding strategy")
+	}
+	if p.OffloadParameters && !p.Strategy.shardsParameters() {
+		return fmt.Errorf("parameter offload requires zero3, fsdp-full or fsdp-hybrid sharding")
+	}
+	return nil
+}
+
+// String describes the sharding
+func (p ShardingParams) String() string {
+	strategy := p.Strategy
+	if strategy == "" {
+		strategy = ShardingNone
+	}
+	s := fmt.Sprintf("%s over %d GPUs", strategy, p.worldSize())
+	if p.Strategy == ShardingFSDPHybrid {
+		s += fmt.Sprintf(" (shard groups of %d)", p.shardGroup())
+	}
+	var offloaded []string
+	if p.OffloadOptimizer {
+		offloaded = append(offloaded, "optimizer")
+	}
+	if p.OffloadParameters {
+		offloaded = append(offloaded, "parameters")
+	}
+	if len(offloaded) > 0 {
+		s += ", " + strings.Join(offloaded, " and ") + " offloaded to CPU"
+	}
+	return s
+}
+
+// trainingStates are the per-GPU training components in GB before overhead
+type trainingStates struct {
+	weightsGB   float64
+	gradientsGB float64
+	optimizerGB float64
+	hostGB      float64 // Offloaded to the host by one GPU's process
+}
+
+// shard partitions the states of a full replica over the shard group. With
+// sharded parameters each GPU gathers the full parameters of the layer it runs
+// and prefetches the next, so two layers stay resident on top of the shard.
+func (p ShardingParams) shard(states trainingStates, layers int) trainingStates {
+	group := float64(p.shardGroup())
+	sharded := states
+
+	if p.Strategy.shardsOptimizer() {
+		sharded.optimizerGB = states.optimizerGB / group
+	}
+	if p.Strategy.shardsGradients() {
+		sharded.gradientsGB = states.gradientsGB / group
+	}
+
+	var gathered float64
+	if p.Strategy.shardsParameters() {
+		sharded.weightsGB = states.weightsGB / group
+		if group > 1 && layers > 0 {
+			gathered = min(2*states.weightsGB/float64(layers), states.weightsGB)
+		}
+	}
+
+	if p.OffloadOptimizer {
+		sharded.hostGB += sharded.optimizerGB
+		sharded.optimizerGB = 0
+	}
+	if p.OffloadParameters {
+		sharded.hostGB += sharded.weightsGB
+		sharded.weightsGB = 0
+	}
+	sharded.weightsGB += gathered
+	return sharded
+}
+
+// hostGBPerNode returns the host memory of the processes sharing a node
+func (p ShardingParams) hostGBPerNode(hostGBPerGPU float64) float64 {
+	return hostGBPerGPU * float64(min(p.worldSize(), p.gpusPerNode()))
+}
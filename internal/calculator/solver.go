@@ -0,0 +1,169 @@
+// internal/calculator/solver.go
+
+package calculator
+
+import "fmt"
+
+// Search limits when the model config doesn't bound the answer
+const (
+	maxSolverUsers   = 1 << 20
+	maxSolverContext = 1 << 24
+)
+
+// SolveParams holds the inputs for finding the largest workload that fits a budget
+type SolveParams struct {
+	BudgetGB    float64 // Memory available on each GPU
+	ParametersB float64 // Total parameters in billions
+	WeightsGB   float64 // Single-GPU weight memory from CalculateWeightMemory
+	KV          KVCacheParams
+	Parallel    ParallelConfig // Zero values are treated as a single GPU
+	Overhead    OverheadModel
+	Activations *ActivationParams // Prefill activations at each context length, nil to leave out
+}
+
+// parallel returns the parallel config with unset sizes defaulted to 1
+func (p SolveParams) parallel() ParallelConfig {
+	return ParallelConfig{
+		TensorParallel:   max(p.Parallel.TensorParallel, 1),
+		PipelineParallel: max(p.Parallel.PipelineParallel, 1),
+	}
+}
+
+// maxPositions returns the longest context the model supports, including RoPE
+// scaling, or 0 if unknown
+func (p SolveParams) maxPositions() int {
+	if p.KV.Config == nil {
+		return 0
+	}
+	return p.KV.Config.ContextLimits().Max
+}
+
+// requiredGB returns the memory of the busiest GPU for a number of users and
+// context length
+func (p SolveParams) requiredGB(users, contextLength int) (float64, error) {
+	kv := p.KV
+	kv.Users = users
+	kv.ContextLength = contextLength
+
+	kvMemory, err := kvCacheGB(p.ParametersB, kv)
+	if err != nil {
+		return 0, err
+	}
+
+	var activations float64
+	if p.Activations != nil {
+		params := *p.Activations
+		params.ContextLength = contextLength
+		activations, err = CalculateActivationMemory(params, p.parallel().TensorParallel)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	perGPU, err := gpuBreakdowns(ParallelParams{
+		ParametersB:   p.ParametersB,
+		WeightsGB:     p.WeightsGB,
+		ActivationsGB: activations,
+		KVCacheGB:     kvMemory,
+		KV:            kv,
+		Parallel:      p.parallel(),
+		Overhead:      p.Overhead,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return PeakBreakdown(perGPU).Total(), nil
+}
+
+// validate checks the budget, overhead and parallel config
+func (p SolveParams) validate() error {
+	if p.BudgetGB <= 0 {
+		return fmt.Errorf("memory budget must be positive")
+	}
+	if err := p.Overhead.Validate(); err != nil {
+		return err
+	}
+	return p.parallel().Validate()
+}
+
+// MaxUsers returns the most concurrent users at KV.ContextLength that fit the
+// budget. It returns 0 when not even one user fits.
+func MaxUsers(params SolveParams) (int, error) {
+	if err := params.validate(); err != nil {
+		return 0, err
+	}
+	contextLength := params.KV.ContextLength
+	if contextLength < 1 {
+		return 0, fmt.Errorf("context length must be at least 1")
+	}
+	if limit := params.maxPositions(); limit > 0 && contextLength > limit {
+		return 0, fmt.Errorf("context length %d exceeds the model's maximum of %d tokens",
+			contextLength, limit)
+	}
+
+	return maxFitting(maxSolverUsers, func(users int) (bool, error) {
+		required, err := params.requiredGB(users, contextLength)
+		return required <= params.BudgetGB, err
+	})
+}
+
+// MaxContextLength returns the longest context per user that fits the budget for
+// KV.Users concurrent users, capped at max_position_embeddings. It returns 0 when
+// not even a single token fits.
+func MaxContextLength(params SolveParams) (int, error) {
+	if err := params.validate(); err != nil {
+		return 0, err
+	}
+	users := params.KV.Users
+	if users < 1 {
+		return 0, fmt.Errorf("number of users must be at least 1")
+	}
+
+	limit := maxSolverContext
+	if positions := params.maxPositions(); positions > 0 {
+		limit = positions
+	}
+
+	return maxFitting(limit, func(contextLength int) (bool, error) {
+		required, err := params.requiredGB(users, contextLength)
+		return required <= params.BudgetGB, err
+	})
+}
+
+// maxFitting returns the largest n in [0, limit] for which fits holds, assuming
+// fits is monotonic. It doubles n to bracket the answer, then binary searches.
+func maxFitting(limit int, fits func(int) (bool, error)) (int, error) {
+	low, high := 0, 1
+	for {
+		if high > limit {
+			high = limit + 1
+			break
+		}
+		ok, err := fits(high)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			break
+		}
+		if high == limit {
+			return limit, nil
+		}
+		low, high = high, high*2
+	}
+
+	// low fits (or is 0), high does not
+	for high-low > 1 {
+		mid := low + (high-low)/2
+		ok, err := fits(mid)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return low, nil
+}
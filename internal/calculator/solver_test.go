@@ -0,0 +1,114 @@
+// internal/calculator/solver_test.go
+
+package calculator
+
+import "testing"
+
+func TestMaxUsers(t *testing.T) {
+	// Llama-3-8B in bfloat16 caches 1 GiB per user at 8K context
+	base := SolveParams{
+		ParametersB: 8.03,
+		WeightsGB:   16.06,
+		KV:          KVCacheParams{ContextLength: 8192, KVDataType: BFloat16, Config: llama3Config()},
+	}
+
+	tests := []struct {
+		name     string
+		budgetGB float64
+		parallel ParallelConfig
+		overhead OverheadModel
+		want     int
+	}{
+		{"h100", 80, ParallelConfig{}, OverheadModel{}, 63},
+		{"h100 with the default overhead", 80, ParallelConfig{}, DefaultOverhead(), 62},
+		{"l4", 24, ParallelConfig{}, OverheadModel{}, 7},
+		{"weights alone overflow", 16, ParallelConfig{}, OverheadModel{}, 0},
+		{"tensor parallel halves the per-GPU cache", 24, ParallelConfig{TensorParallel: 2, PipelineParallel: 1}, OverheadModel{}, 31},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := base
+			params.BudgetGB = tt.budgetGB
+			params.Parallel = tt.parallel
+			params.Overhead = tt.overhead
+			got, err := MaxUsers(params)
+			if err != nil {
+				t.Fatalf("MaxUsers() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("MaxUsers() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	params := base
+	params.BudgetGB = 80
+	params.KV.ContextLength = 16384
+	if _, err := MaxUsers(params); err == nil {
+		t.Error("expected an error for a context beyond max_position_embeddings")
+	}
+	params.BudgetGB = 0
+	if _, err := MaxUsers(params); err == nil {
+		t.Error("expected an error for a zero budget")
+	}
+	params.BudgetGB = 80
+	params.KV.ContextLength = 8192
+	params.Overhead = OverheadModel{Proportional: 1.5}
+	if _, err := MaxUsers(params); err == nil {
+		t.Error("expected an error for an invalid overhead model")
+	}
+}
+
+func TestMaxContextLength(t *testing.T) {
+	params := SolveParams{
+		BudgetGB:    24,
+		ParametersB: 8.03,
+		WeightsGB:   16.06,
+		KV:          KVCacheParams{Users: 1, KVDataType: BFloat16, Config: llama3Config()},
+	}
+
+	// One user fits the whole trained context of 8192 tokens
+	if got, err := MaxContextLength(params); err != nil || got != 8192 {
+		t.Errorf("MaxContextLength() = %d, %v, want 8192", got, err)
+	}
+
+	// Eight users share the 7.94 GiB left after the weights at 128 KiB per token
+	params.KV.Users = 8
+	got, err := MaxContextLength(params)
+	if err != nil {
+		t.Fatalf("MaxContextLength() error = %v", err)
+	}
+	if got < 8100 || got >= 8192 {
+		t.Errorf("MaxContextLength() = %d, want about 8130 tokens", got)
+	}
+	if required, _ := params.requiredGB(8, got); required > params.BudgetGB {
+		t.Errorf("%d tokens need %.2f GB, over the %.0f GB budget", got, required, params.BudgetGB)
+	}
+	if required, _ := params.requiredGB(8, got+1); required <= params.BudgetGB {
+		t.Errorf("%d tokens still fit in %.2f GB", got+1, required)
+	}
+}
+
+func TestMaxFitting(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		fits  func(int) bool
+		want  int
+	}{
+		{"threshold", 1000, func(n int) bool { return n <= 37 }, 37},
+		{"power of two", 1000, func(n int) bool { return n <= 64 }, 64},
+		{"everything fits", 100, func(int) bool { return true }, 100},
+		{"nothing fits", 100, func(int) bool { return false }, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := maxFitting(tt.limit, func(n int) (bool, error) { return tt.fits(n), nil })
+			if err != nil || got != tt.want {
+				t.Errorf("maxFitting() = %d, %v, want %d", got, err, tt.want)
+			}
+		})
+	}
+}
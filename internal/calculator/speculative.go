@@ -0,0 +1,134 @@
+// internal/calculator/speculative.go
+
+package calculator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SpeculativeMethod identifies how speculative decoding proposes tokens
+type SpeculativeMethod string
+
+// Supported speculative decoding methods
+const (
+	SpeculativeDraft  SpeculativeMethod = "draft"  // A smaller model of the same family
+	SpeculativeEAGLE  SpeculativeMethod = "eagle"  // A single decoder layer on the target's hidden states
+	SpeculativeMedusa SpeculativeMethod = "medusa" // Extra decoding heads without attention
+	SpeculativeMTP    SpeculativeMethod = "mtp"    // Built-in multi-token prediction layers
+)
+
+// SpeculativeHeads are the speculative decoding layers a config defines
+type SpeculativeHeads struct {
+	Method SpeculativeMethod
+	Layers int // MTP decoder layers, Medusa heads or EAGLE decoder layers
+}
+
+// SpeculativeHeads detects built-in multi-token prediction layers, Medusa heads
+// and EAGLE draft heads
+func (c *ModelConfig) SpeculativeHeads() (SpeculativeHeads, bool) {
+	switch {
+	case c.NumNextnPredictLayers > 0:
+		return SpeculativeHeads{Method: SpeculativeMTP, Layers: c.NumNextnPredictLayers}, true
+	case c.MedusaNumHeads > 0:
+		return SpeculativeHeads{Method: SpeculativeMedusa, Layers: c.MedusaNumHeads}, true
+	}
+	for _, arch := range c.Architectures {
+		if strings.Contains(strings.ToLower(arch), "eagle") {
+			return SpeculativeHeads{Method: SpeculativeEAGLE, Layers: max(c.NumHiddenLayers, 1)}, true
+		}
+	}
+	return SpeculativeHeads{}, false
+}
+
+// String describes the heads
+func (h SpeculativeHeads) String() string {
+	switch h.Method {
+	case SpeculativeMTP:
+		return fmt.Sprintf("multi-token prediction (%d layers)", h.Layers)
+	case SpeculativeMedusa:
+		return fmt.Sprintf("Medusa (%d heads)", h.Layers)
+	default:
+		return fmt.Sprintf("EAGLE (%d decoder layers)", h.Layers)
+	}
+}
+
+// DraftParams describes the draft model of speculative decoding
+type DraftParams struct {
+	ParametersB       float64 // Draft parameters in billions
+	DataType          DataType
+	Config            *ModelConfig // Nil to estimate the KV cache from the parameter count
+	SpeculativeTokens int          // Tokens proposed per step
+}
+
+// DraftMemory is the memory the draft model adds to the target model
+type DraftMemory struct {
+	Method           SpeculativeMethod
+	WeightsGB        float64
+	KVCacheGB        float64
+	KVCachePerRankGB float64 // KV cache on each tensor-parallel rank, KVCacheGB split evenly when 0
+}
+
+// CalculateDraftMemory returns the weights and KV cache of a draft model serving
+// the same users as the target. Its cache holds the context plus the proposed
+// tokens; Medusa heads have no attention and cache nothing.
+func CalculateDraftMemory(draft DraftParams, kv KVCacheParams) (DraftMemory, error) {
+	if draft.SpeculativeTokens < 0 {
+		return DraftMemory{}, fmt.Errorf("number of speculative tokens must not be negative")
+	}
+	weights, err := CalculateWeightMemory(draft.ParametersB, draft.DataType)
+	if err != nil {
+		return DraftMemory{}, err
+	}
+
+	memory := DraftMemory{Method: SpeculativeDraft, WeightsGB: weights}
+	if draft.Config != nil {
+		if heads, ok := draft.Config.SpeculativeHeads(); ok && heads.Method != SpeculativeMTP {
+			memory.Method = heads.Method
+		}
+	}
+	if memory.Method == SpeculativeMedusa {
+		return memory, nil
+	}
+
+	kv.ContextLength += draft.SpeculativeTokens
+	kv.DataType = draft.DataType
+	kv.Config = draft.Config
+	memory.KVCacheGB, err = kvCacheGB(draft.ParametersB, kv)
+	if err != nil {
+		return DraftMemory{}, err
+	}
+	return memory, nil
+}
+
+// CalculateMTPKVCache returns the KV cache of a model's built-in multi-token
+// prediction layers on each of tensorParallel ranks. The layers attend over the
+// context plus the proposed tokens, and like the model's own layers replicate
+// KV heads when there are fewer than ranks. Their weights are part of the
+// checkpoint and already counted.
+func CalculateMTPKVCache(kv KVCacheParams, speculativeTokens, tensorParallel int) (float64, error) {
+	config := kv.Config
+	if config == nil {
+		return 0, fmt.Errorf("model config is required for multi-token prediction KV cache calculation")
+	}
+	heads, ok := config.SpeculativeHeads()
+	if !ok || heads.Method != SpeculativeMTP {
+		return 0, nil
+	}
+
+	kvDataType := kv.kvDataType()
+	if !ValidateKVDataType(kvDataType) {
+		return 0, ErrUnsupportedDataType{kvDataType}
+	}
+	bytes, _ := BytesPerParameter(kvDataType)
+
+	// MTP layers are full-attention decoder layers like the model's own
+	kind := attentionFull
+	if config.usesLatentAttention() {
+		kind = attentionLatent
+	}
+	tokens := float64(kv.ContextLength + speculativeTokens)
+	kvHeads := config.kvHeadsPerRank(max(tensorParallel, 1))
+	elements := tokens * config.kvElementsPerToken(kind, kvHeads) * float64(heads.Layers)
+	return round(elements*bytes*float64(kv.Users)/bytesPerGiB, 2), nil
+}
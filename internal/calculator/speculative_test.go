@@ -0,0 +1,173 @@
+// internal/calculator/speculative_test.go
+
+package calculator
+
+import "testing"
+
+// llama32Config returns the config of meta-llama/Llama-3.2-1B, a common draft
+// model for the Llama 3 family
+func llama32Config() *ModelConfig {
+	return &ModelConfig{ModelType: "llama", HiddenSize: 2048, NumAttentionHeads: 32, NumHiddenLayers: 16,
+		NumKeyValueHeads: 8, HeadDim: 64, IntermediateSize: 8192, VocabSize: 128256}
+}
+
+func TestSpeculativeHeads(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *ModelConfig
+		want   SpeculativeHeads
+		ok     bool
+	}{
+		{"deepseek v3 mtp", &ModelConfig{NumNextnPredictLayers: 1}, SpeculativeHeads{SpeculativeMTP, 1}, true},
+		{"medusa", &ModelConfig{MedusaNumHeads: 5}, SpeculativeHeads{SpeculativeMedusa, 5}, true},
+		{"eagle", &ModelConfig{Architectures: []string{"LlamaForCausalLMEagle"}, NumHiddenLayers: 1},
+			SpeculativeHeads{SpeculativeEAGLE, 1}, true},
+		{"plain decoder", llama3Config(), SpeculativeHeads{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := tt.config.SpeculativeHeads()
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: SpeculativeHeads() = %+v, %v, want %+v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCalculateDraftMemory(t *testing.T) {
+	kv := KVCacheParams{Users: 4, ContextLength: 8192, DataType: BFloat16, Config: llama3Config()}
+
+	tests := []struct {
+		name        string
+		draft       DraftParams
+		wantMethod  SpeculativeMethod
+		wantWeights float64
+		wantKV      float64
+	}{
+		{
+			// 32 KiB per token over the context plus the 5 proposed tokens
+			name:        "llama 3.2 1b draft",
+			draft:       DraftParams{ParametersB: 1.24, DataType: BFloat16, Config: llama32Config(), SpeculativeTokens: 5},
+			wantMethod:  SpeculativeDraft,
+			wantWeights: 2.48,
+			wantKV:      round(4*8197*32*1024.0/bytesPerGiB, 2),
+		},
+		{
+			name: "eagle head",
+			draft: DraftParams{ParametersB: 0.25, DataType: BFloat16, SpeculativeTokens: 5,
+				Config: &ModelConfig{Architectures: []string{"LlamaForCausalLMEagle"}, HiddenSize: 4096,
+					NumAttentionHeads: 32, NumHiddenLayers: 1, NumKeyValueHeads: 8}},
+			wantMethod:  SpeculativeEAGLE,
+			wantWeights: 0.5,
+			wantKV:      round(4*8197*2*8*128*2.0/bytesPerGiB, 2),
+		},
+		{
+			name:        "medusa heads cache nothing",
+			draft:       DraftParams{ParametersB: 0.5, DataType: BFloat16, Config: &ModelConfig{MedusaNumHeads: 5}},
+			wantMethod:  SpeculativeMedusa,
+			wantWeights: 1,
+		},
+		{
+			name:        "unknown architecture estimates the cache",
+			draft:       DraftParams{ParametersB: 1.24, DataType: BFloat16},
+			wantMethod:  SpeculativeDraft,
+			wantWeights: 2.48,
+			wantKV:      EstimateKVCache(1.24, 4, 8192, BFloat16),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CalculateDraftMemory(tt.draft, kv)
+			if err != nil {
+				t.Fatalf("CalculateDraftMemory() error = %v", err)
+			}
+			if got.Method != tt.wantMethod || got.WeightsGB != tt.wantWeights || got.KVCacheGB != tt.wantKV {
+				t.Errorf("CalculateDraftMemory() = %+v, want %s with %.2f GB weights and %.2f GB KV cache",
+					got, tt.wantMethod, tt.wantWeights, tt.wantKV)
+			}
+		})
+	}
+
+	negative := DraftParams{ParametersB: 1.24, DataType: BFloat16, SpeculativeTokens: -1}
+	if _, err := CalculateDraftMemory(negative, kv); err == nil {
+		t.Error("expected an error for negative speculative tokens")
+	}
+}
+
+// glm45AirConfig returns the config of zai-org/GLM-4.5-Air, which has one
+// multi-token prediction layer
+func glm45AirConfig() *ModelConfig {
+	return &ModelConfig{ModelType: "glm4_moe", HiddenSize: 4096, NumHiddenLayers: 46,
+		NumAttentionHeads: 96, NumKeyValueHeads: 8, HeadDim: 128, NumNextnPredictLayers: 1}
+}
+
+func TestCalculateMTPKVCache(t *testing.T) {
+	// deepseek-ai/DeepSeek-V3 caches an MLA latent in its MTP layer
+	deepseek := &ModelConfig{ModelType: "deepseek_v3", HiddenSize: 7168, NumHiddenLayers: 61,
+		NumAttentionHeads: 128, NumKeyValueHeads: 128, KVLoraRank: 512, QKRopeHeadDim: 64,
+		NumNextnPredictLayers: 1}
+
+	tests := []struct {
+		name   string
+		config *ModelConfig
+		want   float64 // Bytes for all users
+	}{
+		{"grouped-query attention", glm45AirConfig(), 4 * 32771 * 2 * 8 * 128 * 2.0},
+		{"latent attention", deepseek, 4 * 32771 * (512 + 64) * 2},
+		{"no mtp layers", llama3Config(), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kv := KVCacheParams{Users: 4, ContextLength: 32768, DataType: BFloat16, Config: tt.config}
+			got, err := CalculateMTPKVCache(kv, 3, 1)
+			if err != nil {
+				t.Fatalf("CalculateMTPKVCache() error = %v", err)
+			}
+			if want := round(tt.want/bytesPerGiB, 2); got != want {
+				t.Errorf("CalculateMTPKVCache() = %.2f GB, want %.2f GB", got, want)
+			}
+		})
+	}
+
+	if _, err := CalculateMTPKVCache(KVCacheParams{Users: 1, ContextLength: 1}, 3, 1); err == nil {
+		t.Error("expected an error without a model config")
+	}
+}
+
+// MTP layers replicate KV heads over tensor-parallel ranks like the model's own layers
+func TestCalculateMTPKVCachePerRank(t *testing.T) {
+	config := glm45AirConfig()
+	kv := KVCacheParams{Users: 4, ContextLength: 32768, DataType: BFloat16, Config: config}
+
+	single, err := CalculateMTPKVCache(kv, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		tp   int
+		want float64 // Fraction of the single-GPU cache on each rank
+	}{
+		{1, 1},
+		{4, 1.0 / 4},
+		{8, 1.0 / 8},
+		{16, 1.0 / 8}, // One of 8 KV heads on every rank
+	}
+	for _, tt := range tests {
+		got, err := CalculateMTPKVCache(kv, 3, tt.tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := round(single*tt.want, 2); got < want-0.01 || got > want+0.01 {
+			t.Errorf("tp %d: %.2f GB per rank, want %.2f GB", tt.tp, got, want)
+		}
+	}
+
+	// The last stage holds the per-rank cache, not the cache split over the ranks
+	perRank, _ := CalculateMTPKVCache(kv, 3, 16)
+	draft := DraftMemory{Method: SpeculativeMTP, KVCacheGB: single, KVCachePerRankGB: perRank}
+	params := ParallelParams{Parallel: ParallelConfig{TensorParallel: 16, PipelineParallel: 1}, Draft: draft}
+	if _, got := params.draftShare(0, 0); got != perRank {
+		t.Errorf("draftShare added %.2f GB of MTP KV cache per rank, want %.2f GB", got, perRank)
+	}
+}
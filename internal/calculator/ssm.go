@@ -0,0 +1,149 @@
+// internal/calculator/ssm.go
+
+package calculator
+
+// stateSpaceFamilies lists families whose layers all keep a recurrent state
+// instead of a KV cache
+var stateSpaceFamilies = map[string]bool{
+	"mamba":        true,
+	"mamba2":       true,
+	"falcon_mamba": true,
+	"rwkv":         true,
+	"rwkv5":        true,
+	"rwkv6":        true,
+	"rwkv7":        true,
+}
+
+// parallelHybridFamilies lists families that run attention and a Mamba mixer
+// side by side in every layer
+var parallelHybridFamilies = map[string]bool{
+	"falcon_h1": true,
+}
+
+// rwkvFamilies lists the RWKV families, whose state is sized differently from Mamba's
+var rwkvFamilies = map[string]bool{
+	"rwkv":  true,
+	"rwkv5": true,
+	"rwkv6": true,
+	"rwkv7": true,
+}
+
+// Mamba's default expansion of the hidden size in its mixer
+const defaultMambaExpand = 2
+
+// hasStateSpaceLayers reports whether any layer keeps a recurrent state
+func (c *ModelConfig) hasStateSpaceLayers() bool {
+	family := c.family()
+	return stateSpaceFamilies[family] || parallelHybridFamilies[family] ||
+		len(c.LayersBlockType) == c.NumHiddenLayers && c.NumHiddenLayers > 0 ||
+		c.AttnLayerPeriod > 0 && c.mambaStateSize() > 0
+}
+
+// stateSpaceLayerKinds fills the kind of every layer of a state-space or
+// hybrid model:
+//   - Mamba and RWKV have no attention layers
+//   - Falcon-H1 runs attention and Mamba in every layer
+//   - Zamba lists "mamba" and "hybrid" layers in layers_block_type, a hybrid
+//     layer adds the shared attention block to the Mamba layer
+//   - Jamba puts attention in layers where i % attn_layer_period == attn_layer_offset
+func (c *ModelConfig) stateSpaceLayerKinds(kinds []attentionKind) {
+	family := c.family()
+	for i := range kinds {
+		switch {
+		case stateSpaceFamilies[family]:
+			kinds[i] = attentionSSM
+		case parallelHybridFamilies[family]:
+			kinds[i] = attentionHybrid
+		case len(c.LayersBlockType) == len(kinds):
+			switch c.LayersBlockType[i] {
+			case "mamba":
+				kinds[i] = attentionSSM
+			case "hybrid":
+				kinds[i] = attentionHybrid
+			}
+		case i%c.AttnLayerPeriod != c.AttnLayerOffset:
+			kinds[i] = attentionSSM
+		}
+	}
+}
+
+// mambaStateSize returns d_state under its various config names
+func (c *ModelConfig) mambaStateSize() int {
+	return firstPositive(c.StateSize, c.DState, c.MambaDState)
+}
+
+// mambaInnerSize returns d_inner, the hidden size expanded by expand
+func (c *ModelConfig) mambaInnerSize() float64 {
+	expand := c.Expand
+	if expand == 0 {
+		expand = c.MambaExpand
+	}
+	if expand == 0 {
+		expand = defaultMambaExpand
+	}
+	return expand * float64(c.HiddenSize)
+}
+
+// ssmStateElements returns the recurrent state of one layer for one sequence,
+// given the tensor-parallel ranks the mixer is split across. Its size doesn't
+// depend on the context length.
+//
+// A Mamba mixer of width d_inner = expand * hidden_size keeps an SSM state of
+// d_inner * d_state and the last d_conv - 1 inputs of its convolution, which
+// for Mamba-2 also covers the B and C projections of its n_groups. RWKV keeps
+// the previous token's input to time and channel mixing, plus the wkv state:
+// a head_size x head_size matrix per head from RWKV-5 on, three vectors in RWKV-4.
+func (c *ModelConfig) ssmStateElements(tensorParallel int) float64 {
+	tp := float64(max(tensorParallel, 1))
+	hidden := float64(c.HiddenSize)
+
+	if rwkvFamilies[c.family()] {
+		attention := float64(firstPositive(c.AttentionHiddenSize, c.HiddenSize))
+		if c.HeadSize > 0 {
+			return (2*hidden + attention*float64(c.HeadSize)) / tp
+		}
+		return (2*hidden + 3*attention) / tp
+	}
+
+	inner := c.mambaInnerSize()
+	state := float64(c.mambaStateSize())
+	conv := float64(max(firstPositive(c.ConvKernel, c.DConv, c.MambaDConv)-1, 0))
+	groups := float64(firstPositive(c.NGroups, c.MambaNGroups))
+
+	return (inner*state + (inner+2*groups*state)*conv) / tp
+}
+
+// stateElementsPerRank returns the recurrent state of one sequence on a
+// tensor-parallel rank holding layers [firstLayer, lastLayer)
+func (c *ModelConfig) stateElementsPerRank(firstLayer, lastLayer, tensorParallel int) float64 {
+	var elements float64
+	for _, kind := range c.layerKinds()[firstLayer:lastLayer] {
+		if kind == attentionSSM || kind == attentionHybrid {
+			elements += c.ssmStateElements(tensorParallel)
+		}
+	}
+	return elements
+}
+
+// stateDataType returns the data type of recurrent states. Engines keep them in
+// the model's activation data type whatever the KV cache data type: the weight
+// data type when it is a 16- or 32-bit float, else torch_dtype, else float16.
+func (p KVCacheParams) stateDataType() DataType {
+	candidates := []DataType{p.DataType}
+	if p.Config != nil {
+		candidates = append(candidates, DataType(p.Config.TorchDtype))
+	}
+	for _, dtype := range candidates {
+		switch dtype = NormalizeDataType(dtype); dtype {
+		case Float32, Float16, BFloat16:
+			return dtype
+		}
+	}
+	return Float16
+}
+
+// stateBytes returns the bytes of one recurrent state element
+func (p KVCacheParams) stateBytes() float64 {
+	bytes, _ := BytesPerParameter(p.stateDataType())
+	return bytes
+}
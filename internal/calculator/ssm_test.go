@@ -0,0 +1,144 @@
+// internal/calculator/ssm_test.go
+
+package calculator
+
+import "testing"
+
+func TestCalculateKVCacheStateSpace(t *testing.T) {
+	const bf16 = 2.0
+
+	tests := []struct {
+		name   string
+		config *ModelConfig
+		params KVCacheParams
+		want   float64 // Bytes for all users
+	}{
+		{
+			// d_inner * d_state plus the last three convolution inputs per layer
+			name:   "mamba keeps a state independent of the context",
+			config: mambaConfig(),
+			params: KVCacheParams{Users: 100, ContextLength: 131072},
+			want:   100 * 64 * (5120*16 + 5120*3) * bf16,
+		},
+		{
+			name: "mamba-2 convolves the B and C projections",
+			config: &ModelConfig{ModelType: "mamba2", HiddenSize: 2560, NumHiddenLayers: 64,
+				StateSize: 128, ConvKernel: 4, Expand: 2, NGroups: 1},
+			params: KVCacheParams{Users: 10, ContextLength: 4096},
+			want:   10 * 64 * (5120*128 + (5120+2*128)*3) * bf16,
+		},
+		{
+			// A 64x64 wkv matrix per head, RWKV/v6-Finch-1B6-HF
+			name: "rwkv6",
+			config: &ModelConfig{ModelType: "rwkv6", HiddenSize: 2048, NumHiddenLayers: 24,
+				AttentionHiddenSize: 2048, HeadSize: 64},
+			params: KVCacheParams{Users: 10, ContextLength: 4096},
+			want:   10 * 24 * (2*2048 + 2048*64) * bf16,
+		},
+		{
+			// AI21 reports 4 GB of KV cache for a 256K context
+			name:   "jamba caches only its four attention layers",
+			config: jambaConfig(),
+			params: KVCacheParams{Users: 1, ContextLength: 262144},
+			want:   4*262144*2*8*128*bf16 + 28*(8192*16+8192*3)*bf16,
+		},
+		{
+			name: "zamba hybrid layers add attention to the mamba state",
+			config: &ModelConfig{ModelType: "zamba", HiddenSize: 2560, NumHiddenLayers: 4,
+				NumAttentionHeads: 32, NumKeyValueHeads: 32, AttentionHeadDim: 160, HeadDim: 160,
+				MambaDState: 16, MambaDConv: 4, MambaExpand: 2,
+				LayersBlockType: []string{"mamba", "mamba", "hybrid", "mamba"}},
+			params: KVCacheParams{Users: 1, ContextLength: 4096},
+			want:   4096*2*32*160*bf16 + 4*(5120*16+5120*3)*bf16,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			params.Config = tt.config
+			params.KVDataType = BFloat16
+
+			got, err := CalculateKVCache(params)
+			if err != nil {
+				t.Fatalf("CalculateKVCache: %v", err)
+			}
+			if want := round(tt.want/bytesPerGiB, 2); got != want {
+				t.Errorf("CalculateKVCache() = %.2f GB, want %.2f GB", got, want)
+			}
+		})
+	}
+}
+
+func TestSSMStateElementsPerRank(t *testing.T) {
+	config := mambaConfig()
+	single := config.ssmStateElements(1)
+	if want := 5120.0*16 + 5120*3; single != want {
+		t.Fatalf("ssmStateElements(1) = %.0f, want %.0f", single, want)
+	}
+	if got := config.ssmStateElements(4); got != single/4 {
+		t.Errorf("ssmStateElements(4) = %.0f, want %.0f", got, single/4)
+	}
+}
+
+func TestCalculateKVCacheStateDataType(t *testing.T) {
+	const jambaState = 28 * (8192*16 + 8192*3) // Elements of the 28 Mamba layers
+
+	tests := []struct {
+		name   string
+		params KVCacheParams
+		want   float64 // Bytes for one user
+	}{
+		{
+			name:   "fp8 KV cache keeps the state in the model's bfloat16",
+			params: KVCacheParams{DataType: BFloat16, KVDataType: FP8E4M3},
+			want:   4*262144*2*8*128 + jambaState*2,
+		},
+		{
+			name:   "float32 model",
+			params: KVCacheParams{DataType: Float32, KVDataType: FP8E4M3},
+			want:   4*262144*2*8*128 + jambaState*4,
+		},
+		{
+			name:   "quantized weights fall back to float16",
+			params: KVCacheParams{DataType: Int4, KVDataType: BFloat16},
+			want:   4*262144*2*8*128*2 + jambaState*2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			params.Users, params.ContextLength, params.Config = 1, 262144, jambaConfig()
+
+			got, err := CalculateKVCache(params)
+			if err != nil {
+				t.Fatalf("CalculateKVCache: %v", err)
+			}
+			if want := round(tt.want/bytesPerGiB, 2); got != want {
+				t.Errorf("CalculateKVCache() = %.2f GB, want %.2f GB", got, want)
+			}
+		})
+	}
+}
+
+func TestStateDataType(t *testing.T) {
+	awq := mambaConfig()
+	awq.TorchDtype = "bfloat16"
+
+	tests := []struct {
+		name   string
+		params KVCacheParams
+		want   DataType
+	}{
+		{"weight data type", KVCacheParams{DataType: "bf16", KVDataType: FP8E4M3}, BFloat16},
+		{"torch_dtype of a quantized checkpoint", KVCacheParams{DataType: "4.156bpw", Config: awq}, BFloat16},
+		{"nothing known", KVCacheParams{DataType: Int8}, Float16},
+	}
+
+	for _, tt := range tests {
+		if got := tt.params.stateDataType(); got != tt.want {
+			t.Errorf("%s: stateDataType() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
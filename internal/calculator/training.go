@@ -0,0 +1,265 @@
+// internal/calculator/training.go
+
+package calculator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TrainingMethod selects which parameters are trained
+type TrainingMethod string
+
+// Supported training methods
+const (
+	TrainingFull  TrainingMethod = "full"  // Full fine-tuning of every parameter
+	TrainingLoRA  TrainingMethod = "lora"  // LoRA adapters on frozen base weights
+	TrainingQLoRA TrainingMethod = "qlora" // LoRA adapters on NF4-quantized base weights
+)
+
+// trainingMethods lists the supported methods in display order
+var trainingMethods = []TrainingMethod{TrainingFull, TrainingLoRA, TrainingQLoRA}
+
+// GetTrainingMethods returns the supported training methods
+func GetTrainingMethods() []TrainingMethod {
+	return trainingMethods
+}
+
+// ParseTrainingMethod finds a training method by name, ignoring case
+func ParseTrainingMethod(name string) (TrainingMethod, bool) {
+	method := TrainingMethod(strings.ToLower(strings.TrimSpace(name)))
+	for _, known := range trainingMethods {
+		if method == known {
+			return method, true
+		}
+	}
+	return "", false
+}
+
+// DescribeTrainingMethods lists the supported training methods
+func DescribeTrainingMethods() string {
+	names := make([]string, len(trainingMethods))
+	for i, method := range trainingMethods {
+		names[i] = string(method)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Optimizer identifies a training optimizer
+type Optimizer string
+
+// Supported optimizers
+const (
+	OptimizerAdamW     Optimizer = "adamw"
+	OptimizerAdamW8bit Optimizer = "adamw-8bit"
+	OptimizerAdafactor Optimizer = "adafactor"
+	OptimizerSGD       Optimizer = "sgd"
+)
+
+// OptimizerInfo describes the state an optimizer keeps per trainable parameter
+type OptimizerInfo struct {
+	Name        Optimizer
+	Aliases     []Optimizer
+	StateBytes  float64 // Bytes of optimizer state per trainable parameter
+	Description string
+}
+
+// optimizers lists the supported optimizers in display order
+var optimizers = []OptimizerInfo{
+	{Name: OptimizerAdamW, Aliases: []Optimizer{"adam"}, StateBytes: 8,
+		Description: "fp32 first and second moments"},
+	{Name: OptimizerAdamW8bit, Aliases: []Optimizer{"adamw8bit", "adam8bit", "adamw_bnb_8bit"}, StateBytes: 2,
+		Description: "bitsandbytes 8-bit moments"},
+	{Name: OptimizerAdafactor, StateBytes: 4,
+		Description: "factored second moment, roughly 4 bytes per parameter"},
+	{Name: OptimizerSGD, StateBytes: 4,
+		Description: "fp32 momentum"},
+}
+
+// GetOptimizers returns the supported optimizers
+func GetOptimizers() []OptimizerInfo {
+	return optimizers
+}
+
+// LookupOptimizer finds an optimizer by name or alias, ignoring case
+func LookupOptimizer(name string) (OptimizerInfo, bool) {
+	optimizer := Optimizer(strings.ToLower(strings.TrimSpace(name)))
+	for _, info := range optimizers {
+		if info.Name == optimizer {
+			return info, true
+		}
+		for _, alias := range info.Aliases {
+			if alias == optimizer {
+				return info, true
+			}
+		}
+	}
+	return OptimizerInfo{}, false
+}
+
+// DescribeOptimizers lists the supported optimizer names
+func DescribeOptimizers() string {
+	names := make([]string, len(optimizers))
+	for i, info := range optimizers {
+		names[i] = string(info.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// TrainingParams holds the inputs for estimating training memory
+type TrainingParams struct {
+	ParametersB           float64 // Total parameters in billions
+	Method                TrainingMethod
+	DataType              DataType // Weights, gradients and activations, defaults to bfloat16
+	MasterWeights         bool     // Full fine-tuning keeps fp32 master weights of half-precision parameters
+	Optimizer             Optimizer
+	MicroBatch            int
+	SequenceLength        int
+	GradientCheckpointing bool           // Only layer inputs are stored and layers are recomputed
+	LoRA                  LoRAParams     // Adapters for the LoRA and QLoRA methods
+	Sharding              ShardingParams // Data parallelism, a single GPU by default
+	Config                *ModelConfig
+	Overhead              OverheadModel
+}
+
+// TrainingEstimate is the memory of a training step on each data-parallel GPU
+type TrainingEstimate struct {
+	Breakdown           MemoryBreakdown
+	BaseDataType        DataType // Data type of the base weights
+	TrainableParameters int64
+	HostRAMGB           float64 // Host memory per node for offloaded states
+}
+
+// dataType returns the training data type, defaulting to bfloat16
+func (p TrainingParams) dataType() DataType {
+	if p.DataType == "" {
+		return BFloat16
+	}
+	return p.DataType
+}
+
+// usesLoRA reports whether only adapters are trained
+func (p TrainingParams) usesLoRA() bool {
+	return p.Method == TrainingLoRA || p.Method == TrainingQLoRA
+}
+
+// validate checks the method, optimizer, batch shape and adapters
+func (p TrainingParams) validate() error {
+	if _, ok := ParseTrainingMethod(string(p.Method)); !ok {
+		return fmt.Errorf("unknown training method %q (%s)", p.Method, DescribeTrainingMethods())
+	}
+	if _, ok := LookupOptimizer(string(p.Optimizer)); !ok {
+		return fmt.Errorf("unknown optimizer %q (%s)", p.Optimizer, DescribeOptimizers())
+	}
+	if info, ok := GetDataTypeInfo(p.dataType()); !ok || info.Family != FamilyFloat {
+		return fmt.Errorf("training data type must be a floating point type, got %s", p.dataType())
+	}
+	if p.MicroBatch < 1 || p.SequenceLength < 1 {
+		return fmt.Errorf("micro-batch size and sequence length must be at least 1")
+	}
+	if p.Config == nil {
+		return fmt.Errorf("model config is required for training memory calculation")
+	}
+	if p.usesLoRA() {
+		if err := p.LoRA.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := p.Sharding.Validate(); err != nil {
+		return err
+	}
+	return p.Overhead.Validate()
+}
+
+// CalculateTrainingMemory estimates the memory of a training step on each GPU:
+//   - weights: the base model in the training data type (NF4 for QLoRA) plus fp32 adapters
+//   - gradients: one per trainable parameter, in fp32 for adapters
+//   - optimizer: the optimizer states and, for mixed precision, fp32 master weights
+//   - activations: those stored for the backward pass, see trainingActivationsGB
+//
+// The sharding strategy then partitions the states over the data-parallel GPUs
+// and moves offloaded ones to host memory. Activations are never sharded.
+func CalculateTrainingMemory(params TrainingParams) (TrainingEstimate, error) {
+	if err := params.validate(); err != nil {
+		return TrainingEstimate{}, err
+	}
+	optimizer, _ := LookupOptimizer(string(params.Optimizer))
+
+	dtype := params.dataType()
+	bytes, ok := BytesPerParameter(dtype)
+	if !ok {
+		return TrainingEstimate{}, ErrUnsupportedDataType{dtype}
+	}
+
+	baseDataType := dtype
+	if params.Method == TrainingQLoRA {
+		baseDataType = NF4
+	}
+	weights, err := CalculateWeightMemory(params.ParametersB, baseDataType)
+	if err != nil {
+		return TrainingEstimate{}, err
+	}
+
+	var trainable int64
+	var gradients, states float64
+	if params.usesLoRA() {
+		// PEFT keeps adapters, their gradients and the optimizer's view of them in fp32
+		trainable, err = CalculateLoRAParameters(params.Config, params.LoRA)
+		if err != nil {
+			return TrainingEstimate{}, err
+		}
+		adapters := float64(trainable) * bytesPerFloat32 / 1e9
+		weights += adapters
+		gradients = adapters
+		states = float64(trainable) * optimizer.StateBytes / 1e9
+	} else {
+		trainable = int64(params.ParametersB * 1e9)
+		gradients = float64(trainable) * bytes / 1e9
+		states = float64(trainable) * optimizer.StateBytes / 1e9
+		if params.MasterWeights && bytes < bytesPerFloat32 {
+			states += float64(trainable) * bytesPerFloat32 / 1e9
+		}
+	}
+
+	activations := trainingActivationsGB(params.Config, params.MicroBatch*params.SequenceLength,
+		bytes, params.GradientCheckpointing)
+
+	sharded := params.Sharding.shard(trainingStates{
+		weightsGB:   weights,
+		gradientsGB: gradients,
+		optimizerGB: states,
+	}, params.Config.NumHiddenLayers)
+
+	breakdown := NewMemoryBreakdown("GPU", params.Sharding.worldSize(), sharded.weightsGB, activations, 0, params.Overhead)
+	breakdown.GradientsGB = round(sharded.gradientsGB, 2)
+	breakdown.OptimizerGB = round(sharded.optimizerGB, 2)
+
+	return TrainingEstimate{
+		Breakdown:           breakdown,
+		BaseDataType:        baseDataType,
+		TrainableParameters: trainable,
+		HostRAMGB:           round(params.Sharding.hostGBPerNode(sharded.hostGB), 2),
+	}, nil
+}
+
+// trainingActivationsGB estimates the activations stored for the backward pass
+// of tokens tokens in GiB. Each decoder layer keeps the inputs of its two norms,
+// attention and MLP, the q/k/v projections, the attention output and the gate,
+// up, activated and gated MLP intermediates; FlashAttention recomputes the
+// attention scores. With gradient checkpointing only each layer's input is kept
+// and one layer is recomputed at a time. The fp32 logits and their gradient are
+// added on top.
+func trainingActivationsGB(config *ModelConfig, tokens int, bytes float64, checkpointing bool) float64 {
+	hidden := float64(config.HiddenSize)
+	layers := float64(config.NumHiddenLayers)
+	attention := float64(config.NumAttentionHeads * config.headDim())
+
+	perLayer := 4*hidden + float64(config.qkvWidth()) + attention + 4*float64(config.activeIntermediateSize())
+	stored := layers * perLayer
+	if checkpointing {
+		stored = layers*hidden + perLayer
+	}
+
+	logits := 2 * float64(config.VocabSize) * bytesPerFloat32
+	return float64(tokens) * (stored*bytes + logits) / bytesPerGiB
+}
@@ -0,0 +1,309 @@
+// internal/calculator/training_test.go
+
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateTrainingMemory(t *testing.T) {
+	// Full fine-tuning with mixed-precision AdamW keeps 16 bytes per parameter:
+	// bf16 weights and gradients, fp32 master weights and two fp32 moments
+	tests := []struct {
+		name          string
+		params        TrainingParams
+		wantWeights   float64
+		wantGradients float64
+		wantOptimizer float64
+		wantTrainable int64
+	}{
+		{
+			name:          "full fine-tuning with adamw",
+			params:        TrainingParams{Method: TrainingFull, MasterWeights: true, Optimizer: OptimizerAdamW},
+			wantWeights:   16.06,
+			wantGradients: 16.06,
+			wantOptimizer: 8.03 * (8 + 4),
+			wantTrainable: 8030000000,
+		},
+		{
+			name:          "full fine-tuning with 8-bit adamw",
+			params:        TrainingParams{Method: TrainingFull, Optimizer: OptimizerAdamW8bit},
+			wantWeights:   16.06,
+			wantGradients: 16.06,
+			wantOptimizer: 8.03 * 2,
+			wantTrainable: 8030000000,
+		},
+		{
+			name:          "fp32 training has no master copy",
+			params:        TrainingParams{Method: TrainingFull, DataType: Float32, MasterWeights: true, Optimizer: OptimizerSGD},
+			wantWeights:   32.12,
+			wantGradients: 32.12,
+			wantOptimizer: 8.03 * 4,
+			wantTrainable: 8030000000,
+		},
+		{
+			// fp32 adapters on bfloat16 weights
+			name:          "lora",
+			params:        TrainingParams{Method: TrainingLoRA, Optimizer: OptimizerAdamW, LoRA: LoRAParams{Rank: 16}},
+			wantWeights:   16.06 + 41943040*4/1e9,
+			wantGradients: 41943040 * 4 / 1e9,
+			wantOptimizer: 41943040 * 8 / 1e9,
+			wantTrainable: 41943040,
+		},
+		{
+			// NF4 base weights with one fp32 absmax per block of 64
+			name:          "qlora",
+			params:        TrainingParams{Method: TrainingQLoRA, Optimizer: OptimizerAdamW, LoRA: LoRAParams{Rank: 16}},
+			wantWeights:   4.52 + 41943040*4/1e9,
+			wantGradients: 41943040 * 4 / 1e9,
+			wantOptimizer: 41943040 * 8 / 1e9,
+			wantTrainable: 41943040,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			params.ParametersB = 8.03
+			params.MicroBatch = 1
+			params.SequenceLength = 2048
+			params.Config = llama3Config()
+
+			estimate, err := CalculateTrainingMemory(params)
+			if err != nil {
+				t.Fatalf("CalculateTrainingMemory: %v", err)
+			}
+			if diff := estimate.TrainableParameters - tt.wantTrainable; diff < -1 || diff > 1 {
+				t.Errorf("TrainableParameters = %d, want %d", estimate.TrainableParameters, tt.wantTrainable)
+			}
+			b := estimate.Breakdown
+			for _, c := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"WeightsGB", b.WeightsGB, tt.wantWeights},
+				{"GradientsGB", b.GradientsGB, tt.wantGradients},
+				{"OptimizerGB", b.OptimizerGB, tt.wantOptimizer},
+			} {
+				if math.Abs(c.got-c.want) > 0.01 {
+					t.Errorf("%s = %.2f, want %.2f", c.name, c.got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateTrainingMemorySharding(t *testing.T) {
+	// Full fine-tuning of 8.03B in bfloat16 with AdamW and fp32 master weights
+	const (
+		weightsGB   = 16.06
+		gradientsGB = 16.06
+		optimizerGB = 8.03 * (8 + 4)
+		layerGB     = weightsGB / 32
+	)
+
+	tests := []struct {
+		name          string
+		sharding      ShardingParams
+		wantWeights   float64
+		wantGradients float64
+		wantOptimizer float64
+		wantHost      float64 // Per node
+	}{
+		{
+			name:          "single GPU",
+			sharding:      ShardingParams{},
+			wantWeights:   weightsGB,
+			wantGradients: gradientsGB,
+			wantOptimizer: optimizerGB,
+		},
+		{
+			name:          "zero1 shards the optimizer",
+			sharding:      ShardingParams{Strategy: ShardingZeRO1, DataParallel: 8},
+			wantWeights:   weightsGB,
+			wantGradients: gradientsGB,
+			wantOptimizer: optimizerGB / 8,
+		},
+		{
+			name:          "zero2 shards gradients too",
+			sharding:      ShardingParams{Strategy: ShardingZeRO2, DataParallel: 8},
+			wantWeights:   weightsGB,
+			wantGradients: gradientsGB / 8,
+			wantOptimizer: optimizerGB / 8,
+		},
+		{
+			name:          "zero3 gathers two layers on top of the shard",
+			sharding:      ShardingParams{Strategy: ShardingZeRO3, DataParallel: 8},
+			wantWeights:   weightsGB/8 + 2*layerGB,
+			wantGradients: gradientsGB / 8,
+			wantOptimizer: optimizerGB / 8,
+		},
+		{
+			name:          "hybrid sharding within a node",
+			sharding:      ShardingParams{Strategy: ShardingFSDPHybrid, DataParallel: 32, GPUsPerNode: 8},
+			wantWeights:   weightsGB/8 + 2*layerGB,
+			wantGradients: gradientsGB / 8,
+			wantOptimizer: optimizerGB / 8,
+		},
+		{
+			name: "offloaded optimizer and parameters",
+			sharding: ShardingParams{Strategy: ShardingFSDPFull, DataParallel: 8,
+				OffloadOptimizer: true, OffloadParameters: true},
+			wantWeights:   2 * layerGB,
+			wantGradients: gradientsGB / 8,
+			wantHost:      8 * (optimizerGB/8 + weightsGB/8),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimate, err := CalculateTrainingMemory(TrainingParams{
+				ParametersB:    8.03,
+				Method:         TrainingFull,
+				MasterWeights:  true,
+				Optimizer:      OptimizerAdamW,
+				MicroBatch:     1,
+				SequenceLength: 2048,
+				Sharding:       tt.sharding,
+				Config:         llama3Config(),
+			})
+			if err != nil {
+				t.Fatalf("CalculateTrainingMemory: %v", err)
+			}
+			b := estimate.Breakdown
+			for _, c := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"WeightsGB", b.WeightsGB, tt.wantWeights},
+				{"GradientsGB", b.GradientsGB, tt.wantGradients},
+				{"OptimizerGB", b.OptimizerGB, tt.wantOptimizer},
+				{"HostRAMGB", estimate.HostRAMGB, tt.wantHost},
+			} {
+				if math.Abs(c.got-c.want) > 0.01 {
+					t.Errorf("%s = %.2f, want %.2f", c.name, c.got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTrainingActivations(t *testing.T) {
+	// Per token and layer: four hidden-sized inputs, q/k/v, the attention output
+	// and four MLP intermediates; the fp32 logits and their gradient on top
+	perLayer := 4*4096 + 6144 + 4096 + 4*14336.0
+	logits := 2 * 128256 * 4.0
+
+	tests := []struct {
+		name          string
+		microBatch    int
+		checkpointing bool
+		stored        float64 // Activation elements per token
+	}{
+		{"every layer stored", 1, false, 32 * perLayer},
+		{"gradient checkpointing", 1, true, 32*4096 + perLayer},
+		{"micro-batch of four", 4, true, 32*4096 + perLayer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimate, err := CalculateTrainingMemory(TrainingParams{
+				ParametersB:           8.03,
+				Method:                TrainingFull,
+				Optimizer:             OptimizerAdamW,
+				MicroBatch:            tt.microBatch,
+				SequenceLength:        2048,
+				GradientCheckpointing: tt.checkpointing,
+				Config:                llama3Config(),
+			})
+			if err != nil {
+				t.Fatalf("CalculateTrainingMemory: %v", err)
+			}
+			tokens := float64(tt.microBatch * 2048)
+			want := round(tokens*(tt.stored*2+logits)/bytesPerGiB, 2)
+			if got := estimate.Breakdown.ActivationsGB; got != want {
+				t.Errorf("ActivationsGB = %.2f, want %.2f", got, want)
+			}
+		})
+	}
+}
+
+func TestCalculateTrainingMemoryErrors(t *testing.T) {
+	valid := TrainingParams{
+		ParametersB:    8.03,
+		Method:         TrainingLoRA,
+		Optimizer:      OptimizerAdamW,
+		MicroBatch:     1,
+		SequenceLength: 2048,
+		LoRA:           LoRAParams{Rank: 16},
+		Config:         llama3Config(),
+	}
+	if _, err := CalculateTrainingMemory(valid); err != nil {
+		t.Fatalf("CalculateTrainingMemory: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*TrainingParams)
+	}{
+		{"unknown method", func(p *TrainingParams) { p.Method = "dpo" }},
+		{"unknown optimizer", func(p *TrainingParams) { p.Optimizer = "lion" }},
+		{"integer data type", func(p *TrainingParams) { p.DataType = Int8 }},
+		{"empty micro-batch", func(p *TrainingParams) { p.MicroBatch = 0 }},
+		{"missing config", func(p *TrainingParams) { p.Config = nil }},
+		{"lora rank 0", func(p *TrainingParams) { p.LoRA.Rank = 0 }},
+		{"offload without sharding", func(p *TrainingParams) { p.Sharding = ShardingParams{OffloadOptimizer: true} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := valid
+			tt.modify(&params)
+			if _, err := CalculateTrainingMemory(params); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestLookupOptimizer(t *testing.T) {
+	tests := []struct {
+		name string
+		want Optimizer
+	}{
+		{"adamw", OptimizerAdamW},
+		{"Adam", OptimizerAdamW},
+		{"adamw_bnb_8bit", OptimizerAdamW8bit},
+		{" adafactor ", OptimizerAdafactor},
+	}
+	for _, tt := range tests {
+		if info, ok := LookupOptimizer(tt.name); !ok || info.Name != tt.want {
+			t.Errorf("LookupOptimizer(%q) = %s, %v, want %s", tt.name, info.Name, ok, tt.want)
+		}
+	}
+	if _, ok := LookupOptimizer("lion"); ok {
+		t.Error("LookupOptimizer(lion) found an optimizer")
+	}
+}
+
+func TestParseShardingStrategy(t *testing.T) {
+	tests := []struct {
+		name string
+		want ShardingStrategy
+	}{
+		{"zero3", ShardingZeRO3},
+		{"DDP", ShardingNone},
+		{"fsdp", ShardingFSDPFull},
+		{"shard_grad_op", ShardingZeRO2},
+		{" hybrid_shard ", ShardingFSDPHybrid},
+	}
+	for _, tt := range tests {
+		if got, ok := ParseShardingStrategy(tt.name); !ok || got != tt.want {
+			t.Errorf("ParseShardingStrategy(%q) = %s, %v, want %s", tt.name, got, ok, tt.want)
+		}
+	}
+	if _, ok := ParseShardingStrategy("zero4"); ok {
+		t.Error("ParseShardingStrategy(zero4) found a strategy")
+	}
+}
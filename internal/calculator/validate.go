@@ -0,0 +1,127 @@
+// internal/calculator/validate.go
+
+package calculator
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMissingField represents a config.json field the calculations need
+type ErrMissingField struct {
+	Field string
+}
+
+func (e ErrMissingField) Error() string {
+	return fmt.Sprintf("config is missing %s", e.Field)
+}
+
+// ErrInvalidField represents a config.json dimension with an impossible value
+type ErrInvalidField struct {
+	Field string
+	Value int
+}
+
+func (e ErrInvalidField) Error() string {
+	return fmt.Sprintf("config has invalid %s: %d", e.Field, e.Value)
+}
+
+// ErrInconsistentHeads represents KV heads that can't be shared evenly by the
+// attention heads
+type ErrInconsistentHeads struct {
+	Heads   int
+	KVHeads int
+}
+
+func (e ErrInconsistentHeads) Error() string {
+	return fmt.Sprintf("num_attention_heads (%d) is not a multiple of num_key_value_heads (%d)",
+		e.Heads, e.KVHeads)
+}
+
+// ErrInconsistentHeadDim represents a hidden size that doesn't split into the
+// attention heads of a config without head_dim
+type ErrInconsistentHeadDim struct {
+	HiddenSize int
+	Heads      int
+}
+
+func (e ErrInconsistentHeadDim) Error() string {
+	return fmt.Sprintf("hidden_size (%d) is not a multiple of num_attention_heads (%d) and head_dim is not set",
+		e.HiddenSize, e.Heads)
+}
+
+// ErrEstimateRefused represents a strict calculation that would have fallen
+// back to the parameter-based KV cache estimate
+type ErrEstimateRefused struct {
+	Reason string
+}
+
+func (e ErrEstimateRefused) Error() string {
+	return fmt.Sprintf("strict mode: refusing to estimate the KV cache: %s", e.Reason)
+}
+
+// Validate checks that the config has the dimensions the KV cache, parameter
+// and activation calculations divide by. It returns every problem found,
+// joined, so errors.As finds each typed error.
+func (c *ModelConfig) Validate() error {
+	var errs []error
+	for _, field := range []struct {
+		name  string
+		value int
+	}{
+		{"hidden_size", c.HiddenSize},
+		{"num_hidden_layers", c.NumHiddenLayers},
+		{"num_attention_heads", c.NumAttentionHeads},
+		{"num_key_value_heads", c.NumKeyValueHeads},
+		{"head_dim", c.HeadDim},
+		{"intermediate_size", c.IntermediateSize},
+		{"vocab_size", c.VocabSize},
+		{"sliding_window", c.SlidingWindow},
+		{"max_position_embeddings", c.MaxPositionEmbeddings},
+	} {
+		if field.value < 0 {
+			errs = append(errs, ErrInvalidField{field.name, field.value})
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if c.HiddenSize == 0 {
+		errs = append(errs, ErrMissingField{"hidden_size"})
+	}
+	if c.NumHiddenLayers == 0 {
+		return errors.Join(append(errs, ErrMissingField{"num_hidden_layers"})...)
+	}
+
+	if c.hasAttentionLayers() && !c.usesLatentAttention() {
+		switch {
+		case c.NumAttentionHeads == 0:
+			errs = append(errs, ErrMissingField{"num_attention_heads"})
+		case c.NumKeyValueHeads == 0:
+			errs = append(errs, ErrMissingField{"num_key_value_heads"})
+		case c.NumKeyValueHeads > c.NumAttentionHeads || c.NumAttentionHeads%c.NumKeyValueHeads != 0:
+			errs = append(errs, ErrInconsistentHeads{c.NumAttentionHeads, c.NumKeyValueHeads})
+		}
+		if c.NumAttentionHeads > 0 && c.HeadDim == 0 && c.AttentionHeadDim == 0 &&
+			c.HiddenSize%c.NumAttentionHeads != 0 {
+			errs = append(errs, ErrInconsistentHeadDim{c.HiddenSize, c.NumAttentionHeads})
+		}
+	}
+
+	if c.hasStateSpaceLayers() && !rwkvFamilies[c.family()] && c.mambaStateSize() == 0 {
+		errs = append(errs, ErrMissingField{"state_size"})
+	}
+
+	return errors.Join(errs...)
+}
+
+// hasAttentionLayers reports whether any layer attends to cached keys and values
+func (c *ModelConfig) hasAttentionLayers() bool {
+	for _, kind := range c.layerKinds() {
+		if kind != attentionSSM {
+			return true
+		}
+	}
+	return c.IsEncoderDecoder
+}
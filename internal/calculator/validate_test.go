@@ -0,0 +1,100 @@
+// internal/calculator/validate_test.go
+
+package calculator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*ModelConfig)
+		want   []error // Every error errors.Is must find, none for a valid config
+	}{
+		{"valid", func(c *ModelConfig) {}, nil},
+		{
+			name:   "missing dimensions",
+			modify: func(c *ModelConfig) { c.HiddenSize, c.NumHiddenLayers = 0, 0 },
+			want:   []error{ErrMissingField{"hidden_size"}, ErrMissingField{"num_hidden_layers"}},
+		},
+		{
+			name:   "negative dimensions",
+			modify: func(c *ModelConfig) { c.VocabSize, c.SlidingWindow = -1, -4096 },
+			want:   []error{ErrInvalidField{"vocab_size", -1}, ErrInvalidField{"sliding_window", -4096}},
+		},
+		{
+			name:   "missing attention heads",
+			modify: func(c *ModelConfig) { c.NumAttentionHeads = 0 },
+			want:   []error{ErrMissingField{"num_attention_heads"}},
+		},
+		{
+			name:   "KV heads that don't divide the heads",
+			modify: func(c *ModelConfig) { c.NumKeyValueHeads = 5 },
+			want:   []error{ErrInconsistentHeads{32, 5}},
+		},
+		{
+			name:   "more KV heads than heads",
+			modify: func(c *ModelConfig) { c.NumKeyValueHeads = 64 },
+			want:   []error{ErrInconsistentHeads{32, 64}},
+		},
+		{
+			name:   "hidden size that doesn't split into heads",
+			modify: func(c *ModelConfig) { c.HiddenSize = 4100 },
+			want:   []error{ErrInconsistentHeadDim{4100, 32}},
+		},
+		{"explicit head_dim", func(c *ModelConfig) { c.HiddenSize, c.HeadDim = 4100, 128 }, nil},
+		{
+			name: "latent attention needs no KV heads",
+			modify: func(c *ModelConfig) {
+				c.NumKeyValueHeads, c.KVLoraRank, c.QKRopeHeadDim = 0, 512, 64
+			},
+		},
+		{
+			name: "Mamba without heads",
+			modify: func(c *ModelConfig) {
+				*c = ModelConfig{ModelType: "mamba", HiddenSize: 2560, NumHiddenLayers: 64, StateSize: 16}
+			},
+		},
+		{
+			name: "Mamba without a state size",
+			modify: func(c *ModelConfig) {
+				*c = ModelConfig{ModelType: "mamba", HiddenSize: 2560, NumHiddenLayers: 64}
+			},
+			want: []error{ErrMissingField{"state_size"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := llama3Config()
+			tt.modify(config)
+			err := config.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			for _, want := range tt.want {
+				if !errors.Is(err, want) {
+					t.Errorf("Validate() = %v, want it to include %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestStrictRefusesEstimate(t *testing.T) {
+	kv := KVCacheParams{Users: 1, ContextLength: 4096, Strict: true}
+	if _, err := kvCacheGB(8, kv); !errors.Is(err, ErrEstimateRefused{"no model config"}) {
+		t.Errorf("kvCacheGB() = %v, want ErrEstimateRefused", err)
+	}
+
+	// Invalid configs fail the calculations instead of dividing by zero
+	kv.Config = &ModelConfig{HiddenSize: 4096, NumHiddenLayers: 32, NumAttentionHeads: 32, NumKeyValueHeads: 5}
+	if _, err := CalculateKVCache(kv); !errors.Is(err, ErrInconsistentHeads{32, 5}) {
+		t.Errorf("CalculateKVCache() = %v, want ErrInconsistentHeads", err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/Lentz92/huggyfit/internal/calculator"
 )
 
 const huggingFaceAPI = "https://huggingface.co/api/models/%s"
@@ -19,10 +21,8 @@ type HFResponse struct {
 	Downloads   int    `json:"downloads"`
 	Likes       int    `json:"likes"`
 	Safetensors struct {
-		Parameters struct {
-			BF16 int64 `json:"BF16"`
-		} `json:"parameters"`
-		Total int64 `json:"total"`
+		Parameters map[string]int64 `json:"parameters"`
+		Total      int64            `json:"total"`
 	} `json:"safetensors"`
 }
 
@@ -31,9 +31,14 @@ type ModelInfo struct {
 	ModelID     string
 	Author      string
 	ParametersB float64
-	Downloads   int
-	Likes       int
-	FetchedAt   time.Time
+	// ParameterBreakdown maps safetensors dtypes (F32, BF16, I8, ...) to parameter counts
+	ParameterBreakdown map[string]int64
+	// ParametersDerived is set when the parameter count was computed from
+	// config.json, as the repo has no safetensors metadata or packs its weights
+	ParametersDerived bool
+	Downloads         int
+	Likes             int
+	FetchedAt         time.Time
 }
 
 // FetchModelInfo retrieves model information from HuggingFace
@@ -73,16 +78,44 @@ func FetchModelInfo(modelID string) (*ModelInfo, error) {
 	// Convert parameter count to billions
 	paramCount := float64(hfResp.Safetensors.Total) / 1e9
 
+	// Repos with only pytorch_model.bin or GGUF files have no safetensors metadata
+	derived := false
 	if paramCount == 0 {
-		return nil, fmt.Errorf("could not determine parameter count for model: %s", modelID)
+		count, err := deriveParameterCount(modelID)
+		if err != nil {
+			return nil, fmt.Errorf("could not determine parameter count for model %s: %w", modelID, err)
+		}
+		paramCount, derived = float64(count)/1e9, true
+	} else if calculator.HasPackedWeights(hfResp.Safetensors.Parameters) {
+		// Packed GPTQ, AWQ and bitsandbytes tensors count one parameter per element
+		if config, err := calculator.FetchModelConfig(modelID); err == nil {
+			if count, ok := config.UnpackedParameters(); ok {
+				paramCount, derived = float64(count)/1e9, true
+			}
+		}
 	}
 
 	return &ModelInfo{
-		ModelID:     hfResp.ModelID,
-		Author:      hfResp.Author,
-		ParametersB: paramCount,
-		Downloads:   hfResp.Downloads,
-		Likes:       hfResp.Likes,
-		FetchedAt:   time.Now(),
+		ModelID:            hfResp.ModelID,
+		Author:             hfResp.Author,
+		ParametersB:        paramCount,
+		ParameterBreakdown: hfResp.Safetensors.Parameters,
+		ParametersDerived:  derived,
+		Downloads:          hfResp.Downloads,
+		Likes:              hfResp.Likes,
+		FetchedAt:          time.Now(),
 	}, nil
 }
+
+// deriveParameterCount computes the parameter count from the model's config.json
+func deriveParameterCount(modelID string) (int64, error) {
+	config, err := calculator.FetchModelConfig(modelID)
+	if err != nil {
+		return 0, fmt.Errorf("no safetensors metadata and no config: %w", err)
+	}
+	count, ok := config.EstimateParameters()
+	if !ok {
+		return 0, fmt.Errorf("no safetensors metadata and config.json lacks the model dimensions")
+	}
+	return count, nil
+}
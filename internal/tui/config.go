@@ -20,24 +20,48 @@ const (
 	defaultHeight = 30
 )
 
-// Predefined context lengths in tokens
-var contextLengths = []int{
-	2048,  // 2k
-	4096,  // 4k
-	8192,  // 8k
-	16384, // 16k
-	32768, // 32k
+// Context length presets double from minContextLength up to the model's
+// maximum, or up to defaultMaxContextLength while the maximum is unknown
+const (
+	minContextLength        = 2048
+	defaultMaxContextLength = 32768
+)
+
+// contextLengths returns the context length presets up to maxContext, ending
+// with maxContext itself when it is not a power of two (40k, 160k)
+func contextLengths(maxContext int) []int {
+	if maxContext <= 0 {
+		maxContext = defaultMaxContextLength
+	}
+	var lengths []int
+	for length := minContextLength; length <= maxContext; length *= 2 {
+		lengths = append(lengths, length)
+	}
+	if len(lengths) == 0 || lengths[len(lengths)-1] != maxContext {
+		lengths = append(lengths, maxContext)
+	}
+	return lengths
 }
 
 // Predefined user count options
 var userCounts = []int{1, 2, 4, 8, 16, 32}
 
-// Supported data types for memory calculation
-var dataTypes = []calculator.DataType{
-	calculator.Float16,
-	calculator.Int8,
-	calculator.Int4,
-}
+// Predefined tensor and pipeline parallel sizes
+var tensorParallelSizes = []int{1, 2, 4, 8}
+var pipelineParallelSizes = []int{1, 2, 4}
+
+// Training options: micro-batch sizes and the data types compared
+var microBatchSizes = []int{1, 2, 4, 8, 16}
+var trainingDataTypes = []calculator.DataType{calculator.BFloat16, calculator.Float16, calculator.Float32}
+
+// Rank of the LoRA adapters on all linear layers, trained or served
+const loraRank = 16
+
+// Resident LoRA adapters served next to the base model, 0 for none
+var loraAdapterCounts = []int{0, 1, 8, 32}
+
+// Data type families shown in the memory table, one family at a time
+var dataTypeFamilies = calculator.GetDataTypeFamilies()
 
 // getMainContentWidth returns the desired width for the main content area
 func getMainContentWidth() int {
@@ -50,16 +74,68 @@ func getMainContentHeight() int {
 }
 
 // getNextContextLength returns the next available context length
-func getNextContextLength(current int) int {
-	for i, length := range contextLengths {
+func getNextContextLength(lengths []int, current int) int {
+	for i, length := range lengths {
 		if current <= length {
-			if i+1 < len(contextLengths) {
-				return contextLengths[i+1]
+			if i+1 < len(lengths) {
+				return lengths[i+1]
 			}
-			return contextLengths[0]
+			return lengths[0]
 		}
 	}
-	return contextLengths[0]
+	return lengths[0]
+}
+
+// getNextDataTypeFamily returns the next available data type family
+func getNextDataTypeFamily(current calculator.DataTypeFamily) calculator.DataTypeFamily {
+	for i, family := range dataTypeFamilies {
+		if current == family {
+			return dataTypeFamilies[(i+1)%len(dataTypeFamilies)]
+		}
+	}
+	return dataTypeFamilies[0]
+}
+
+// getNextKVDataType returns the next available KV cache data type
+func getNextKVDataType(current calculator.DataType) calculator.DataType {
+	for i, dtype := range calculator.KVCacheTypes {
+		if current == dtype {
+			return calculator.KVCacheTypes[(i+1)%len(calculator.KVCacheTypes)]
+		}
+	}
+	return calculator.KVCacheTypes[0]
+}
+
+// getNextTrainingMethod returns the next available training method
+func getNextTrainingMethod(current calculator.TrainingMethod) calculator.TrainingMethod {
+	methods := calculator.GetTrainingMethods()
+	for i, method := range methods {
+		if current == method {
+			return methods[(i+1)%len(methods)]
+		}
+	}
+	return methods[0]
+}
+
+// getNextOptimizer returns the next available optimizer
+func getNextOptimizer(current calculator.Optimizer) calculator.Optimizer {
+	optimizers := calculator.GetOptimizers()
+	for i, info := range optimizers {
+		if current == info.Name {
+			return optimizers[(i+1)%len(optimizers)].Name
+		}
+	}
+	return optimizers[0].Name
+}
+
+// getNextOption returns the option following current, wrapping around
+func getNextOption(options []int, current int) int {
+	for i, option := range options {
+		if current == option {
+			return options[(i+1)%len(options)]
+		}
+	}
+	return options[0]
 }
 
 // getNextUserCount returns the next available user count
@@ -90,9 +166,23 @@ func getPrevUserCount(current int) int {
 
 // formatContextLength formats a context length for display
 func formatContextLength(length int) string {
+	switch {
+	case length >= 1<<20 && length%(1<<20) == 0:
+		return fmt.Sprintf("%dM", length>>20)
+	case length < 1024:
+		return fmt.Sprint(length)
+	}
 	return fmt.Sprintf("%dk", length/1024)
 }
 
+// formatToggle formats an on/off setting for display
+func formatToggle(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
 // getCurrentPage calculates the current page number based on cursor position
 func getCurrentPage(cursor int) int {
 	return cursor / itemsPerPage
@@ -4,6 +4,7 @@ package tui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/Lentz92/huggyfit/internal/calculator"
@@ -21,20 +22,25 @@ func (m Model) renderModelDetails() string {
 	s.WriteString("\n\n")
 
 	// Render content based on active tab
-	if m.activeTab == 0 {
+	switch m.activeTab {
+	case 0:
 		s.WriteString(m.renderMemoryDetails())
-	} else {
+	case 1:
 		s.WriteString(m.renderModelInfo())
+	default:
+		s.WriteString(m.renderTrainingDetails())
 	}
 
 	return detailStyle.Render(s.String())
 }
 
+// detailTabs are the views of the details panel, switched with Tab
+var detailTabs = []string{"Memory Requirements", "Model Details", "Training"}
+
 func (m Model) renderTabs() string {
-	tabs := []string{"Memory Requirements", "Model Details"}
 	var parts []string
 
-	for i, tab := range tabs {
+	for i, tab := range detailTabs {
 		if i == m.activeTab {
 			parts = append(parts, activeTabStyle.Render("("+tab+")"))
 		} else {
@@ -51,47 +57,241 @@ func (m Model) renderMemoryDetails() string {
 	// Model identification and configuration
 	s.WriteString("Model: " + m.modelInfo.ModelID + "  ")
 	s.WriteString("Users: " + valueStyle.Render(fmt.Sprint(m.users)) + "  ")
-	s.WriteString("Context: " + valueStyle.Render(formatContextLength(m.contextLen)) + "\n\n")
+	s.WriteString("Context: " + valueStyle.Render(formatContextLength(m.contextLen)) + "  ")
+	s.WriteString("KV: " + valueStyle.Render(string(m.kvDataType)) + "\n")
+	if detected, ok := m.checkpointDataType(); ok {
+		s.WriteString("Checkpoint: " + valueStyle.Render(string(detected)))
+		if m.modelConfig.QuantizationConfig != nil {
+			s.WriteString(" (" + m.modelConfig.QuantizationConfig.String() + ")")
+		}
+		s.WriteString("\n")
+	}
+	if m.loraAdapters > 0 && m.calcConfig() != nil {
+		s.WriteString(fmt.Sprintf("LoRA: %s adapters of rank %s on all linear layers, included in weights\n",
+			valueStyle.Render(fmt.Sprint(m.loraAdapters)), valueStyle.Render(fmt.Sprint(loraRank))))
+	}
+	if spec, ok := m.selectedGPU(); ok {
+		s.WriteString("GPU: " + valueStyle.Render(spec.String()) +
+			fmt.Sprintf(" (%.0f GB each, ", spec.GPU.MemoryGB) +
+			fitStyle.Render("fits") + " / " + noFitStyle.Render("does not fit") + ")\n")
+	}
+	s.WriteString(m.renderEngineDetails())
+	if m.parallel.GPUs() > 1 {
+		s.WriteString(fmt.Sprintf("Per GPU: TP %s  PP %s  (%s GPUs, busiest stage)\n",
+			valueStyle.Render(fmt.Sprint(m.parallel.TensorParallel)),
+			valueStyle.Render(fmt.Sprint(m.parallel.PipelineParallel)),
+			valueStyle.Render(fmt.Sprint(m.parallel.GPUs()))))
+	}
+	s.WriteString("\n")
 
 	// Header
-	headers := []string{"Type", "Base", "KV Cache", "Total", "Per User"}
-	s.WriteString(fmt.Sprintf("%-8s  %-12s  %-12s  %-12s  %-12s\n",
+	headers := []string{"Type", "Weights", "Overhead", "Activations", "KV Cache", "Total", "Per User"}
+	s.WriteString(fmt.Sprintf("%-8s  %-12s  %-12s  %-12s  %-12s  %-12s  %-12s\n",
 		headerStyle.Render(headers[0]),
 		headerStyle.Render(headers[1]),
 		headerStyle.Render(headers[2]),
 		headerStyle.Render(headers[3]),
-		headerStyle.Render(headers[4])))
-	s.WriteString(strings.Repeat("-", 62) + "\n")
+		headerStyle.Render(headers[4]),
+		headerStyle.Render(headers[5]),
+		headerStyle.Render(headers[6])))
+	s.WriteString(strings.Repeat("-", 90) + "\n")
 
 	// Memory calculations for each data type
-	for _, dtype := range dataTypes {
+	estimated := false
+	for _, dtype := range m.dataTypes() {
 		s.WriteString(m.renderMemoryCalculation(dtype))
+		estimated = estimated || m.kvCacheEstimated(dtype)
+	}
+	if estimated {
+		s.WriteString(m.renderEstimateNote())
+	}
+
+	s.WriteString(m.renderCapacity())
+
+	return s.String()
+}
+
+// renderEngineDetails shows the selected engine's own figures for the first data type
+func (m Model) renderEngineDetails() string {
+	engine, ok := m.selectedEngine()
+	if !ok {
+		return ""
+	}
+	dtypes := m.dataTypes()
+	if len(dtypes) == 0 {
+		return ""
+	}
+	estimate, ok := m.engineEstimate(dtypes[0])
+	if !ok {
+		return ""
+	}
+
+	parts := make([]string, 0, len(estimate.Details))
+	for _, detail := range estimate.Details {
+		parts = append(parts, detail.Name+" "+valueStyle.Render(detail.Value))
+	}
+	return "Engine: " + valueStyle.Render(string(engine.Name())) +
+		" (" + string(dtypes[0]) + ": " + strings.Join(parts, ", ") + ")\n"
+}
+
+// renderCapacity shows the most users and the longest context that fit the
+// selected GPU for each data type
+func (m Model) renderCapacity() string {
+	spec, ok := m.selectedGPU()
+	if !ok {
+		return ""
+	}
+
+	var s strings.Builder
+	s.WriteString("\nCapacity on " + valueStyle.Render(spec.String()) + ":\n")
+	s.WriteString(fmt.Sprintf("%-8s  %-20s  %-20s\n",
+		headerStyle.Render("Type"),
+		headerStyle.Render("Users @ "+formatContextLength(m.contextLen)),
+		headerStyle.Render(fmt.Sprintf("Context @ %d users", m.users))))
+	s.WriteString(strings.Repeat("-", 52) + "\n")
+
+	for _, dtype := range m.dataTypes() {
+		maxUsers, maxContext, ok := m.solveCapacity(dtype)
+		if !ok {
+			continue
+		}
+		s.WriteString(fmt.Sprintf("%-8s  %s  %s\n",
+			string(dtype),
+			valueStyle.Render(fmt.Sprintf("%20d", maxUsers)),
+			valueStyle.Render(fmt.Sprintf("%20d", maxContext))))
 	}
 
 	return s.String()
 }
 
 func (m Model) renderMemoryCalculation(dtype calculator.DataType) string {
-	baseMemory, _ := calculator.CalculateGPUMemory(m.modelInfo.ParametersB, dtype)
-	kvMemory := m.calculateKVCache(dtype)
-	totalMemory := baseMemory + kvMemory
-	perUser := kvMemory / float64(m.users)
-
-	return fmt.Sprintf("%-8s  %s  %s  %s  %s\n",
-		string(dtype),
-		valueStyle.Render(fmt.Sprintf("%6.2f GB", baseMemory)),
-		valueStyle.Render(fmt.Sprintf("%6.2f GB", kvMemory)),
+	breakdown := m.calculatePerGPUMemory(dtype)
+	totalMemory := breakdown.Total()
+	perUser := breakdown.KVCacheGB / float64(m.users)
+
+	// Color the row by whether it fits the selected GPU and, with an engine,
+	// whether its KV cache holds the workload
+	label := fmt.Sprintf("%-8s", string(dtype))
+	if spec, ok := m.selectedGPU(); ok {
+		serves := true
+		if estimate, ok := m.engineEstimate(dtype); ok {
+			serves = estimate.ServesWorkload()
+		}
+		if totalMemory <= spec.GPU.MemoryGB && serves {
+			label = fitStyle.Render(label)
+		} else {
+			label = noFitStyle.Render(label)
+		}
+	}
+
+	// A KV cache estimated from the parameter count is marked with an asterisk
+	kvCache := fmt.Sprintf("%6.2f GB ", breakdown.KVCacheGB)
+	if m.kvCacheEstimated(dtype) {
+		kvCache = fmt.Sprintf("%6.2f GB*", breakdown.KVCacheGB)
+	}
+
+	return fmt.Sprintf("%s  %s  %s  %s  %s  %s  %s\n",
+		label,
+		valueStyle.Render(fmt.Sprintf("%6.2f GB", breakdown.WeightsGB)),
+		valueStyle.Render(fmt.Sprintf("%6.2f GB", breakdown.OverheadGB)),
+		valueStyle.Render(fmt.Sprintf("%6.2f GB", breakdown.ActivationsGB)),
+		valueStyle.Render(kvCache),
 		valueStyle.Render(fmt.Sprintf("%6.2f GB", totalMemory)),
 		valueStyle.Render(fmt.Sprintf("%6.2f GB", perUser)))
 }
 
+// renderEstimateNote explains the asterisk on estimated KV cache values
+func (m Model) renderEstimateNote() string {
+	reason := "no usable config.json"
+	if m.configErr != nil {
+		reason = "invalid config.json: " + m.configErr.Error()
+	}
+	return "* KV cache estimated from the parameter count (" + reason + ")\n"
+}
+
+// renderTrainingDetails shows the memory of a training step for each training
+// data type, with the sequence length taken from the context length
+func (m Model) renderTrainingDetails() string {
+	var s strings.Builder
+
+	s.WriteString("Model: " + m.modelInfo.ModelID + "  ")
+	s.WriteString("Method: " + valueStyle.Render(string(m.trainingMethod)) + "  ")
+	s.WriteString("Optimizer: " + valueStyle.Render(string(m.optimizer)) + "\n")
+	s.WriteString("Sequence: " + valueStyle.Render(formatContextLength(m.contextLen)) + "  ")
+	s.WriteString("Micro-batch: " + valueStyle.Render(fmt.Sprint(m.microBatch)) + "  ")
+	s.WriteString("Checkpointing: " + valueStyle.Render(formatToggle(m.gradientCheckpointing)))
+	if m.trainingMethod != calculator.TrainingFull {
+		s.WriteString("  LoRA rank: " + valueStyle.Render(fmt.Sprint(loraRank)))
+	}
+	s.WriteString("\n\n")
+
+	if m.calcConfig() == nil {
+		s.WriteString("Training estimates need a valid model config")
+		return s.String()
+	}
+
+	// Header
+	headers := []string{"Type", "Weights", "Gradients", "Optimizer", "Activations", "Total"}
+	s.WriteString(fmt.Sprintf("%-8s  %-12s  %-12s  %-12s  %-12s  %-12s\n",
+		headerStyle.Render(headers[0]),
+		headerStyle.Render(headers[1]),
+		headerStyle.Render(headers[2]),
+		headerStyle.Render(headers[3]),
+		headerStyle.Render(headers[4]),
+		headerStyle.Render(headers[5])))
+	s.WriteString(strings.Repeat("-", 76) + "\n")
+
+	for _, dtype := range trainingDataTypes {
+		estimate, ok := m.calculateTrainingMemory(dtype)
+		if !ok {
+			continue
+		}
+		breakdown := estimate.Breakdown
+
+		label := fmt.Sprintf("%-8s", string(dtype))
+		if spec, ok := m.selectedGPU(); ok {
+			if breakdown.Total() <= spec.GPU.MemoryGB {
+				label = fitStyle.Render(label)
+			} else {
+				label = noFitStyle.Render(label)
+			}
+		}
+
+		s.WriteString(fmt.Sprintf("%s  %s  %s  %s  %s  %s\n",
+			label,
+			valueStyle.Render(fmt.Sprintf("%6.2f GB", breakdown.WeightsGB)),
+			valueStyle.Render(fmt.Sprintf("%6.2f GB", breakdown.GradientsGB)),
+			valueStyle.Render(fmt.Sprintf("%6.2f GB", breakdown.OptimizerGB)),
+			valueStyle.Render(fmt.Sprintf("%6.2f GB", breakdown.ActivationsGB)),
+			valueStyle.Render(fmt.Sprintf("%6.2f GB", breakdown.Total()))))
+	}
+
+	return s.String()
+}
+
 func (m Model) renderModelInfo() string {
 	var s strings.Builder
 
 	// Model metadata
 	s.WriteString("Model ID: " + m.modelInfo.ModelID + "\n")
 	s.WriteString("Author: " + m.modelInfo.Author + "\n")
-	s.WriteString("Parameters: " + valueStyle.Render(fmt.Sprintf("%.2fB", m.modelInfo.ParametersB)) + "\n")
+	s.WriteString("Parameters: " + valueStyle.Render(fmt.Sprintf("%.2fB", m.modelInfo.ParametersB)))
+	if m.modelInfo.ParametersDerived {
+		s.WriteString(" (derived from config.json)")
+	}
+	s.WriteString("\n")
+	for _, dtype := range sortedBreakdownTypes(m.modelInfo.ParameterBreakdown) {
+		count := float64(m.modelInfo.ParameterBreakdown[dtype]) / 1e9
+		s.WriteString("  " + dtype + ": " + valueStyle.Render(fmt.Sprintf("%.2fB", count)) + "\n")
+	}
+
+	if m.calcConfig() != nil {
+		s.WriteString("Attention: " + m.modelConfig.AttentionSummary() + "\n")
+	}
+	if m.modelConfig != nil {
+		s.WriteString("Max Context: " + valueStyle.Render(m.modelConfig.ContextLimits().String()) + "\n")
+	}
+	s.WriteString(m.renderMoEInfo())
 
 	// Usage statistics
 	s.WriteString("\nUsage Statistics:\n")
@@ -104,6 +304,44 @@ func (m Model) renderModelInfo() string {
 	return s.String()
 }
 
+// renderMoEInfo shows total vs active parameters for Mixture-of-Experts models
+func (m Model) renderMoEInfo() string {
+	if m.calcConfig() == nil {
+		return ""
+	}
+	moe, ok := m.modelConfig.AnalyzeMoE(m.modelInfo.ParametersB)
+	if !ok {
+		return ""
+	}
+
+	// Weight sizes are shown for the checkpoint's own data type
+	dtype, ok := m.checkpointDataType()
+	if !ok {
+		dtype = calculator.Float16
+	}
+	weights := m.calculateWeightMemory(dtype) - m.calculateAdapterMemory(dtype)
+
+	var s strings.Builder
+	s.WriteString("\nMixture of Experts:\n")
+	s.WriteString(fmt.Sprintf("Experts: %s routed, %s active, %s shared\n",
+		valueStyle.Render(fmt.Sprint(moe.NumExperts)),
+		valueStyle.Render(fmt.Sprint(moe.ExpertsPerToken)),
+		valueStyle.Render(fmt.Sprint(moe.SharedExperts))))
+	s.WriteString("Total / Active: " + valueStyle.Render(fmt.Sprintf("%.2fB / %.2fB", moe.TotalParamsB, moe.ActiveParamsB)) + "\n")
+	s.WriteString("Per Expert: " + valueStyle.Render(fmt.Sprintf("%.2f GB", weights*moe.ExpertParamsB/moe.TotalParamsB)) +
+		" (" + string(dtype) + ")\n")
+	s.WriteString("Expert Parallel:")
+	for _, ep := range []int{1, 2, 4, 8} {
+		if ep > moe.NumExperts {
+			break
+		}
+		s.WriteString(fmt.Sprintf(" x%d %s", ep, valueStyle.Render(fmt.Sprintf("%.1f GB", weights*moe.ExpertParallelFraction(ep)))))
+	}
+	s.WriteString("\n")
+
+	return s.String()
+}
+
 func (m Model) renderConfigurationOptions() string {
 	var s strings.Builder
 
@@ -122,7 +360,7 @@ func (m Model) renderConfigurationOptions() string {
 
 	// Context length options
 	s.WriteString("\nContext (c):")
-	for i, length := range contextLengths {
+	for i, length := range m.contextLengths() {
 		if i > 0 {
 			s.WriteString(" |")
 		}
@@ -133,5 +371,95 @@ func (m Model) renderConfigurationOptions() string {
 		}
 	}
 
+	// Parallelism options
+	s.WriteString("\nTP (t):")
+	s.WriteString(renderIntOptions(tensorParallelSizes, m.parallel.TensorParallel))
+	s.WriteString("  PP (p):")
+	s.WriteString(renderIntOptions(pipelineParallelSizes, m.parallel.PipelineParallel))
+
+	// GPU options
+	s.WriteString("\nGPU (g): ")
+	if spec, ok := m.selectedGPU(); ok {
+		s.WriteString(selectedStyle.Render(spec.GPU.Name))
+	} else {
+		s.WriteString(selectedStyle.Render("none"))
+	}
+
+	// Serving engine options
+	s.WriteString("  Engine (e): ")
+	if engine, ok := m.selectedEngine(); ok {
+		s.WriteString(selectedStyle.Render(string(engine.Name())))
+	} else {
+		s.WriteString(selectedStyle.Render("none"))
+	}
+
+	// Resident LoRA adapter options
+	s.WriteString("  LoRA (l): ")
+	if m.loraAdapters > 0 {
+		s.WriteString(selectedStyle.Render(fmt.Sprintf("%d x r%d", m.loraAdapters, loraRank)))
+	} else {
+		s.WriteString(selectedStyle.Render("none"))
+	}
+
+	// Training options
+	s.WriteString("\nTraining: Method (m): " + selectedStyle.Render(string(m.trainingMethod)))
+	s.WriteString("  Optimizer (o): " + selectedStyle.Render(string(m.optimizer)))
+	s.WriteString("  Micro-batch (b): " + selectedStyle.Render(fmt.Sprint(m.microBatch)))
+	s.WriteString("  Checkpointing (x): " + selectedStyle.Render(formatToggle(m.gradientCheckpointing)))
+
+	// Data type family options
+	s.WriteString("\nTypes (f):")
+	for i, family := range dataTypeFamilies {
+		if i > 0 {
+			s.WriteString(" |")
+		}
+		if family == m.dtypeFamily {
+			s.WriteString(" " + selectedStyle.Render(string(family)))
+		} else {
+			s.WriteString(" " + string(family))
+		}
+	}
+
+	// KV cache data type options
+	s.WriteString("\nKV cache (v):")
+	for i, dtype := range calculator.KVCacheTypes {
+		if i > 0 {
+			s.WriteString(" |")
+		}
+		if dtype == m.kvDataType {
+			s.WriteString(" " + selectedStyle.Render(string(dtype)))
+		} else {
+			s.WriteString(" " + string(dtype))
+		}
+	}
+
+	return s.String()
+}
+
+// sortedBreakdownTypes returns the stored data types ordered by parameter count
+func sortedBreakdownTypes(breakdown map[string]int64) []string {
+	dtypes := make([]string, 0, len(breakdown))
+	for dtype := range breakdown {
+		dtypes = append(dtypes, dtype)
+	}
+	sort.Slice(dtypes, func(i, j int) bool {
+		return breakdown[dtypes[i]] > breakdown[dtypes[j]]
+	})
+	return dtypes
+}
+
+// renderIntOptions renders numeric options with the current one highlighted
+func renderIntOptions(options []int, current int) string {
+	var s strings.Builder
+	for i, option := range options {
+		if i > 0 {
+			s.WriteString(" |")
+		}
+		if option == current {
+			s.WriteString(" " + selectedStyle.Render(fmt.Sprint(option)))
+		} else {
+			s.WriteString(" " + fmt.Sprint(option))
+		}
+	}
 	return s.String()
 }
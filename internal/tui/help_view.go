@@ -39,6 +39,15 @@ var helpContent = []helpSection{
 		items: []helpItem{
 			{"+/-", "Adjust user count"},
 			{"c", "Cycle context length"},
+			{"f", "Cycle data type family"},
+			{"v", "Cycle KV cache data type"},
+			{"t/p", "Cycle tensor/pipeline parallel size"},
+			{"g", "Cycle GPU for fit check"},
+			{"e", "Cycle serving engine"},
+			{"l", "Cycle resident LoRA adapters"},
+			{"m/o", "Cycle training method/optimizer"},
+			{"b", "Cycle training micro-batch size"},
+			{"x", "Toggle gradient checkpointing"},
 		},
 	},
 	{
@@ -4,6 +4,7 @@ package tui
 
 import (
 	"github.com/Lentz92/huggyfit/internal/cache"
+	"github.com/Lentz92/huggyfit/internal/calculator"
 	"github.com/Lentz92/huggyfit/internal/models"
 )
 
@@ -12,6 +13,10 @@ type errMsg error
 type modelListMsg []string
 type modelInfoMsg *models.ModelInfo
 type cacheUpdateMsg struct {
-	key    cache.CacheKey
-	memory float64
+	key   cache.CacheKey
+	value cache.KVCacheValue
+}
+type modelConfigMsg struct {
+	modelID string
+	config  *calculator.ModelConfig
 }
@@ -16,9 +16,11 @@ import (
 // Model represents the application state
 type Model struct {
 	// Core data
-	modelIDs  []string
-	modelInfo *models.ModelInfo
-	cursor    int
+	modelIDs    []string
+	modelInfo   *models.ModelInfo
+	modelConfig *calculator.ModelConfig
+	configErr   error // Validation error of modelConfig, whose KV cache is then estimated
+	cursor      int
 
 	// UI Components
 	spinner   spinner.Model
@@ -34,9 +36,22 @@ type Model struct {
 	cacheOperationPending bool
 
 	// Configuration
-	users      int
-	contextLen int
-	cache      *cache.Cache
+	users        int
+	contextLen   int
+	kvDataType   calculator.DataType
+	dtypeFamily  calculator.DataTypeFamily
+	parallel     calculator.ParallelConfig
+	gpuIndex     int // Index into the GPU catalog, -1 when no GPU is selected
+	engineIndex  int // Index into the engine profiles, -1 when no engine is selected
+	loraAdapters int // Resident LoRA adapters served with the model
+	overhead     calculator.OverheadModel
+	cache        *cache.Cache
+
+	// Training configuration
+	trainingMethod        calculator.TrainingMethod
+	optimizer             calculator.Optimizer
+	microBatch            int
+	gradientCheckpointing bool
 
 	// Terminal size fields
 	width  int
@@ -61,11 +76,24 @@ func InitialModel() Model {
 		textInput: ti,
 
 		// Set default state
-		loading:    true,
-		activeTab:  0,
-		users:      userCounts[0],
-		contextLen: contextLengths[1],
-		cache:      cache.NewCache(24 * time.Hour),
+		loading:     true,
+		activeTab:   0,
+		users:       userCounts[0],
+		contextLen:  contextLengths(0)[1],
+		kvDataType:  calculator.KVCacheTypes[0],
+		dtypeFamily: dataTypeFamilies[0],
+		parallel: calculator.ParallelConfig{
+			TensorParallel:   tensorParallelSizes[0],
+			PipelineParallel: pipelineParallelSizes[0],
+		},
+		gpuIndex:    -1,
+		engineIndex: -1,
+		overhead:    calculator.DefaultOverhead(),
+		cache:       cache.NewCache(24 * time.Hour),
+
+		trainingMethod: calculator.GetTrainingMethods()[0],
+		optimizer:      calculator.GetOptimizers()[0].Name,
+		microBatch:     microBatchSizes[0],
 
 		// Initialize with default dimensions
 		width:  getMainContentWidth(),
@@ -87,22 +115,279 @@ func (m Model) calculateKVCache(dtype calculator.DataType) float64 {
 		return 0
 	}
 
-	key := cache.CacheKey{
-		ModelID:    m.modelInfo.ModelID,
-		Users:      m.users,
-		ContextLen: m.contextLen,
-		DataType:   dtype,
-	}
-
 	// Return cached value if available
-	if value, exists := m.cache.GetKVCache(key); exists {
-		return value
+	if value, exists := m.cache.GetKVCache(m.cacheKey(dtype)); exists {
+		return value.MemoryGB
 	}
 
 	// Return 0 if calculation is pending
 	return 0
 }
 
+// kvCacheEstimated reports whether the KV cache of a data type was estimated
+// from the parameter count, as the model config is missing or invalid
+func (m Model) kvCacheEstimated(dtype calculator.DataType) bool {
+	if m.modelInfo == nil {
+		return false
+	}
+	value, exists := m.cache.GetKVCache(m.cacheKey(dtype))
+	return exists && value.Estimated
+}
+
+// calcConfig returns the model config the memory calculations use, or nil when
+// it failed validation. Its data type and context limits are still shown.
+func (m Model) calcConfig() *calculator.ModelConfig {
+	if m.configErr != nil {
+		return nil
+	}
+	return m.modelConfig
+}
+
+// contextLengths returns the context length presets up to the selected model's
+// maximum context
+func (m Model) contextLengths() []int {
+	if m.modelConfig == nil {
+		return contextLengths(0)
+	}
+	return contextLengths(m.modelConfig.ContextLimits().Max)
+}
+
+// dataTypes returns the data types of the selected family, preceded by the
+// checkpoint's native and detected data types when they are known
+func (m Model) dataTypes() []calculator.DataType {
+	familyTypes := calculator.GetTypesByFamily(m.dtypeFamily)
+	types := make([]calculator.DataType, 0, len(familyTypes)+2)
+
+	if m.modelInfo != nil && len(m.modelInfo.ParameterBreakdown) > 0 {
+		types = append(types, calculator.Native)
+	}
+	if detected, ok := m.checkpointDataType(); ok && !containsDataType(familyTypes, detected) {
+		types = append(types, detected)
+	}
+
+	return append(types, familyTypes...)
+}
+
+// checkpointDataType returns the weight data type detected from the model config
+func (m Model) checkpointDataType() (calculator.DataType, bool) {
+	if m.modelConfig == nil {
+		return "", false
+	}
+	return m.modelConfig.CheckpointDataType()
+}
+
+// containsDataType reports whether dtype is in types
+func containsDataType(types []calculator.DataType, dtype calculator.DataType) bool {
+	for _, t := range types {
+		if t == dtype {
+			return true
+		}
+	}
+	return false
+}
+
+// calculateWeightMemory calculates the weight memory for a data type
+func (m Model) calculateWeightMemory(dtype calculator.DataType) float64 {
+	if dtype == calculator.Native {
+		memory, _ := calculator.CalculateNativeWeightMemory(m.modelInfo.ParameterBreakdown)
+		return memory + m.calculateAdapterMemory(dtype)
+	}
+	memory, _ := calculator.CalculateQuantizedWeightMemory(m.modelConfig, m.modelInfo.ParametersB, dtype)
+	return memory + m.calculateAdapterMemory(dtype)
+}
+
+// calculateAdapterMemory returns the memory of the resident LoRA adapters
+func (m Model) calculateAdapterMemory(dtype calculator.DataType) float64 {
+	if m.loraAdapters == 0 || m.calcConfig() == nil {
+		return 0
+	}
+	memory, _ := calculator.CalculateLoRAServingMemory(m.calcConfig(), calculator.LoRAServingParams{
+		LoRA:     calculator.LoRAParams{Rank: loraRank},
+		MaxLoRAs: m.loraAdapters,
+	}, dtype)
+	return memory
+}
+
+// calculatePerGPUMemory returns the memory breakdown of the busiest GPU when the
+// model is split with tensor and pipeline parallelism, as allocated by the
+// selected engine
+func (m Model) calculatePerGPUMemory(dtype calculator.DataType) calculator.MemoryBreakdown {
+	if estimate, ok := m.engineEstimate(dtype); ok {
+		return calculator.PeakBreakdown(estimate.PerGPU)
+	}
+
+	weights := m.calculateWeightMemory(dtype)
+	kvMemory := m.calculateKVCache(dtype)
+	single := calculator.NewMemoryBreakdown("GPU", 1, weights, m.calculateActivations(1), kvMemory, m.overhead)
+	if m.parallel.GPUs() == 1 {
+		return single
+	}
+
+	perGPU, err := calculator.CalculateParallelMemory(calculator.ParallelParams{
+		ParametersB:   m.modelInfo.ParametersB,
+		WeightsGB:     weights,
+		ActivationsGB: m.calculateActivations(m.parallel.TensorParallel),
+		KVCacheGB:     kvMemory,
+		KV: calculator.KVCacheParams{
+			Users:         m.users,
+			ContextLength: m.contextLen,
+			DataType:      dtype,
+			KVDataType:    m.kvDataType,
+			Config:        m.calcConfig(),
+		},
+		Parallel: m.parallel,
+		Overhead: m.overhead,
+	})
+	if err != nil {
+		return single
+	}
+
+	return calculator.PeakBreakdown(perGPU)
+}
+
+// activationParams returns the prefill of one sequence over the whole context,
+// or nil without a model config
+func (m Model) activationParams() *calculator.ActivationParams {
+	if m.calcConfig() == nil {
+		return nil
+	}
+	return &calculator.ActivationParams{
+		ContextLength: m.contextLen,
+		Batch:         1,
+		Config:        m.calcConfig(),
+	}
+}
+
+// calculateActivations returns the peak prefill activations per GPU
+func (m Model) calculateActivations(tensorParallel int) float64 {
+	params := m.activationParams()
+	if params == nil {
+		return 0
+	}
+	memory, _ := calculator.CalculateActivationMemory(*params, tensorParallel)
+	return memory
+}
+
+// calculateTrainingMemory returns the memory of a training step at the current
+// context length, or false without a model config
+func (m Model) calculateTrainingMemory(dtype calculator.DataType) (calculator.TrainingEstimate, bool) {
+	if m.calcConfig() == nil {
+		return calculator.TrainingEstimate{}, false
+	}
+	estimate, err := calculator.CalculateTrainingMemory(calculator.TrainingParams{
+		ParametersB:           m.modelInfo.ParametersB,
+		Method:                m.trainingMethod,
+		DataType:              dtype,
+		MasterWeights:         true,
+		Optimizer:             m.optimizer,
+		MicroBatch:            m.microBatch,
+		SequenceLength:        m.contextLen,
+		GradientCheckpointing: m.gradientCheckpointing,
+		LoRA:                  calculator.LoRAParams{Rank: loraRank},
+		Config:                m.calcConfig(),
+		Overhead:              m.overhead,
+	})
+	if err != nil {
+		return calculator.TrainingEstimate{}, false
+	}
+	return estimate, true
+}
+
+// engineEstimate returns the memory the selected engine allocates for a data type
+func (m Model) engineEstimate(dtype calculator.DataType) (calculator.EngineEstimate, bool) {
+	engine, ok := m.selectedEngine()
+	if !ok {
+		return calculator.EngineEstimate{}, false
+	}
+
+	params := calculator.EngineParams{
+		ParametersB: m.modelInfo.ParametersB,
+		WeightsGB:   m.calculateWeightMemory(dtype),
+		KV: calculator.KVCacheParams{
+			Users:         m.users,
+			ContextLength: m.contextLen,
+			DataType:      dtype,
+			KVDataType:    m.kvDataType,
+			Config:        m.calcConfig(),
+		},
+		Parallel: m.parallel,
+		Overhead: m.overhead,
+	}
+	if spec, ok := m.selectedGPU(); ok {
+		params.GPUMemoryGB = spec.GPU.MemoryGB
+	}
+
+	estimate, err := engine.Estimate(params)
+	if err != nil {
+		return calculator.EngineEstimate{}, false
+	}
+	return estimate, true
+}
+
+// selectedEngine returns the serving engine the memory table is modelled for
+func (m Model) selectedEngine() (calculator.EngineProfile, bool) {
+	engines := calculator.GetEngines()
+	if m.engineIndex < 0 || m.engineIndex >= len(engines) {
+		return nil, false
+	}
+	return engines[m.engineIndex], true
+}
+
+// solveCapacity returns the most users at the current context and the longest
+// context for the current users that fit the selected GPU
+func (m Model) solveCapacity(dtype calculator.DataType) (int, int, bool) {
+	spec, ok := m.selectedGPU()
+	if !ok {
+		return 0, 0, false
+	}
+
+	params := calculator.SolveParams{
+		BudgetGB:    spec.GPU.MemoryGB,
+		ParametersB: m.modelInfo.ParametersB,
+		WeightsGB:   m.calculateWeightMemory(dtype),
+		KV: calculator.KVCacheParams{
+			Users:         m.users,
+			ContextLength: m.contextLen,
+			DataType:      dtype,
+			KVDataType:    m.kvDataType,
+			Config:        m.calcConfig(),
+		},
+		Parallel:    m.parallel,
+		Overhead:    m.overhead,
+		Activations: m.activationParams(),
+	}
+
+	maxUsers, err := calculator.MaxUsers(params)
+	if err != nil {
+		return 0, 0, false
+	}
+	maxContext, err := calculator.MaxContextLength(params)
+	if err != nil {
+		return 0, 0, false
+	}
+	return maxUsers, maxContext, true
+}
+
+// selectedGPU returns the GPU spec the memory table is checked against
+func (m Model) selectedGPU() (calculator.GPUSpec, bool) {
+	gpus := calculator.GetGPUs()
+	if m.gpuIndex < 0 || m.gpuIndex >= len(gpus) {
+		return calculator.GPUSpec{}, false
+	}
+	return calculator.GPUSpec{Count: m.parallel.GPUs(), GPU: gpus[m.gpuIndex]}, true
+}
+
+// cacheKey builds the cache key for the current configuration and data type
+func (m Model) cacheKey(dtype calculator.DataType) cache.CacheKey {
+	return cache.CacheKey{
+		ModelID:    m.modelInfo.ModelID,
+		Users:      m.users,
+		ContextLen: m.contextLen,
+		DataType:   dtype,
+		KVDataType: m.kvDataType,
+	}
+}
+
 // isModelSelected returns whether a model is currently selected
 func (m Model) isModelSelected() bool {
 	return m.modelInfo != nil
@@ -142,9 +427,20 @@ func fetchModelInfo(modelID string) tea.Cmd {
 	}
 }
 
+func fetchModelConfig(c *cache.Cache, modelID string) tea.Cmd {
+	return func() tea.Msg {
+		// Gated or non-standard models have no readable config, which is not an error
+		config, _ := c.GetOrFetchConfig(modelID)
+		return modelConfigMsg{modelID: modelID, config: config}
+	}
+}
+
 func performCacheOperation(m *Model, key cache.CacheKey, parameters float64) tea.Cmd {
 	return func() tea.Msg {
-		memory := m.cache.GetOrCalculateKVCache(key, parameters, false)
-		return cacheUpdateMsg{key: key, memory: memory}
+		value, err := m.cache.GetOrCalculateKVCache(key, parameters, false)
+		if err != nil {
+			return errMsg(err)
+		}
+		return cacheUpdateMsg{key: key, value: value}
 	}
 }
@@ -12,6 +12,7 @@ var (
 	errorColor     = lipgloss.Color("#FF0000")
 	highlightColor = lipgloss.Color("#74B2FF")
 	mutedColor     = lipgloss.Color("#626262")
+	fitColor       = lipgloss.Color("#4CAF50")
 
 	// Title styling for the main application header
 	titleStyle = lipgloss.NewStyle().
@@ -70,6 +71,12 @@ var (
 	valueStyle = lipgloss.NewStyle().
 			Foreground(highlightColor)
 
+	// Memory rows that fit or overflow the selected GPU
+	fitStyle = lipgloss.NewStyle().
+			Foreground(fitColor)
+	noFitStyle = lipgloss.NewStyle().
+			Foreground(errorColor)
+
 	// Table header styling
 	headerStyle = lipgloss.NewStyle().
 			Bold(true).
@@ -3,7 +3,7 @@
 package tui
 
 import (
-	"github.com/Lentz92/huggyfit/internal/cache"
+	"github.com/Lentz92/huggyfit/internal/calculator"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -24,6 +24,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleModelList(msg)
 	case modelInfoMsg:
 		return m.handleModelInfo(msg)
+	case modelConfigMsg:
+		return m.handleModelConfig(msg)
 	case cacheUpdateMsg:
 		return m.handleCacheUpdate(msg)
 	case errMsg:
@@ -54,7 +56,7 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case "tab":
 		if m.isModelSelected() {
-			m.activeTab = (m.activeTab + 1) % 2
+			m.activeTab = (m.activeTab + 1) % len(detailTabs)
 		}
 		return m, nil
 	}
@@ -119,9 +121,63 @@ func (m Model) handleNavigationKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	case "c":
 		if m.isModelSelected() {
-			m.contextLen = getNextContextLength(m.contextLen)
+			m.contextLen = getNextContextLength(m.contextLengths(), m.contextLen)
 			return m, m.triggerCacheUpdate()
 		}
+	case "f":
+		if m.isModelSelected() {
+			m.dtypeFamily = getNextDataTypeFamily(m.dtypeFamily)
+			return m, m.triggerCacheUpdate()
+		}
+	case "t":
+		if m.isModelSelected() {
+			m.parallel.TensorParallel = getNextOption(tensorParallelSizes, m.parallel.TensorParallel)
+		}
+	case "p":
+		if m.isModelSelected() {
+			m.parallel.PipelineParallel = getNextOption(pipelineParallelSizes, m.parallel.PipelineParallel)
+		}
+	case "g":
+		if m.isModelSelected() {
+			// Cycle through the catalog, then back to no GPU
+			m.gpuIndex++
+			if m.gpuIndex >= len(calculator.GetGPUs()) {
+				m.gpuIndex = -1
+			}
+		}
+	case "v":
+		if m.isModelSelected() {
+			m.kvDataType = getNextKVDataType(m.kvDataType)
+			return m, m.triggerCacheUpdate()
+		}
+	case "l":
+		if m.isModelSelected() {
+			m.loraAdapters = getNextOption(loraAdapterCounts, m.loraAdapters)
+		}
+	case "m":
+		if m.isModelSelected() {
+			m.trainingMethod = getNextTrainingMethod(m.trainingMethod)
+		}
+	case "o":
+		if m.isModelSelected() {
+			m.optimizer = getNextOptimizer(m.optimizer)
+		}
+	case "b":
+		if m.isModelSelected() {
+			m.microBatch = getNextOption(microBatchSizes, m.microBatch)
+		}
+	case "x":
+		if m.isModelSelected() {
+			m.gradientCheckpointing = !m.gradientCheckpointing
+		}
+	case "e":
+		if m.isModelSelected() {
+			// Cycle through the engines, then back to no engine
+			m.engineIndex++
+			if m.engineIndex >= len(calculator.GetEngines()) {
+				m.engineIndex = -1
+			}
+		}
 	}
 	return m, nil
 }
@@ -141,38 +197,50 @@ func (m Model) handleModelList(msg modelListMsg) (tea.Model, tea.Cmd) {
 func (m Model) handleModelInfo(msg modelInfoMsg) (tea.Model, tea.Cmd) {
 	m.loading = false
 	m.modelInfo = msg
+	m.modelConfig = nil
+	m.configErr = nil
 	m.err = nil
 	m.cacheOperationPending = true
 
-	var cmds []tea.Cmd
-	for _, dtype := range dataTypes {
-		key := cache.CacheKey{
-			ModelID:    m.modelInfo.ModelID,
-			Users:      m.users,
-			ContextLen: m.contextLen,
-			DataType:   dtype,
-		}
-		cmds = append(cmds, performCacheOperation(&m, key, m.modelInfo.ParametersB))
+	cmds := []tea.Cmd{fetchModelConfig(m.cache, m.modelInfo.ModelID)}
+	for _, dtype := range m.dataTypes() {
+		cmds = append(cmds, performCacheOperation(&m, m.cacheKey(dtype), m.modelInfo.ParametersB))
 	}
 	return m, tea.Batch(cmds...)
 }
 
+// handleModelConfig stores the config of the selected model and switches the
+// memory table to the family of the detected checkpoint data type
+func (m Model) handleModelConfig(msg modelConfigMsg) (tea.Model, tea.Cmd) {
+	if m.modelInfo == nil || msg.modelID != m.modelInfo.ModelID || msg.config == nil {
+		return m, nil
+	}
+
+	// An invalid config still gives the data type and context window; only
+	// the KV cache falls back to the estimate, and the table says why
+	m.modelConfig = msg.config
+	m.configErr = msg.config.Validate()
+	if lengths := m.contextLengths(); m.contextLen > lengths[len(lengths)-1] {
+		m.contextLen = lengths[len(lengths)-1] // Beyond what the model supports
+	}
+	if detected, ok := m.checkpointDataType(); ok {
+		if info, registered := calculator.GetDataTypeInfo(detected); registered {
+			m.dtypeFamily = info.Family
+		}
+	}
+	return m, m.triggerCacheUpdate()
+}
+
 // handleCacheUpdate processes cache updates
 func (m Model) handleCacheUpdate(msg cacheUpdateMsg) (tea.Model, tea.Cmd) {
 	// Update cache with the new value
-	m.cache.SetKVCache(msg.key, msg.memory)
+	m.cache.SetKVCache(msg.key, msg.value)
 
 	// Check if there are any remaining cache operations
 	if m.cacheOperationPending {
 		remainingOps := 0
-		for _, dtype := range dataTypes {
-			key := cache.CacheKey{
-				ModelID:    m.modelInfo.ModelID,
-				Users:      m.users,
-				ContextLen: m.contextLen,
-				DataType:   dtype,
-			}
-			if _, exists := m.cache.GetKVCache(key); !exists {
+		for _, dtype := range m.dataTypes() {
+			if _, exists := m.cache.GetKVCache(m.cacheKey(dtype)); !exists {
 				remainingOps++
 			}
 		}
@@ -213,14 +281,8 @@ func (m Model) triggerCacheUpdate() tea.Cmd {
 	}
 
 	var cmds []tea.Cmd
-	for _, dtype := range dataTypes {
-		key := cache.CacheKey{
-			ModelID:    m.modelInfo.ModelID,
-			Users:      m.users,
-			ContextLen: m.contextLen,
-			DataType:   dtype,
-		}
-		cmds = append(cmds, performCacheOperation(&m, key, m.modelInfo.ParametersB))
+	for _, dtype := range m.dataTypes() {
+		cmds = append(cmds, performCacheOperation(&m, m.cacheKey(dtype), m.modelInfo.ParametersB))
 	}
 	return tea.Batch(cmds...)
 }